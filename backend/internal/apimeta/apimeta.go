@@ -0,0 +1,171 @@
+package apimeta
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"MLQueue/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProtocolVersion is the version of the HTTP protocol served by this build.
+// Bump it and add a Changelog entry whenever routes or payloads change.
+const ProtocolVersion = "2.1.0"
+
+type ChangelogEntry struct {
+	Version string   `json:"version"`
+	Changes []string `json:"changes"`
+}
+
+// Changelog lists protocol changes, newest first
+var Changelog = []ChangelogEntry{
+	{
+		Version: "2.1.0",
+		Changes: []string{
+			"Completing a queue issues an Ed25519-signed execution receipt chained per user (key_id, sequence, prev_signature)",
+			"Add receipt download, listing and chain verification; public GET /v2/receipts/public-key and POST /v2/receipts/verify allow offline verification",
+			"Add streamed metric reporting via POST /v2/queues/:queue_id/metrics (NaN/Inf sent as strings) and per-unit anomaly rules",
+			"Add queue command channel (stop, list, acknowledge); heartbeat and metric reports return pending commands, which expire when the queue stops running",
+			"Add GET /meta/routes (route metadata with request/response schema and authorization) and GET /meta/changelog",
+			"Add group-level notification routing (webhook, Slack, email) with unit-level overrides",
+			"Inline V2 queue result/metrics are size-limited (413 PAYLOAD_TOO_LARGE); add streaming payload upload and download; re-uploading a kind replaces and deletes the previous payload",
+			"Completing a queue with result or metrics omitted or empty keeps the existing values; inline values for a streamed field return 409 PAYLOAD_ALREADY_UPLOADED",
+			"Add GET /v1/tasks/:task_id/placement and GET /v2/queues/:queue_id/placement explaining why work has not started",
+			"Training units are snapshotted periodically, on demand and before reordering; add snapshot endpoints including GET /v2/units/:unit_id/snapshot?version=N",
+			"Deleting units and queues moves them to trash; add batch delete, trash listing, restore and purge endpoints; deleting a group permanently deletes its units",
+			"Add service accounts under /v2/service-accounts with read/write scoped keys sharing their owner's rate limit; queues record actor_id",
+			"Users have a role (member/admin); add /v2/admin/reports endpoints with format=csv export",
+		},
+	},
+	{
+		Version: "2.0.0",
+		Changes: []string{
+			"Initial V2 protocol (groups, training units, training queues)",
+		},
+	},
+}
+
+type RouteInfo struct {
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	APIVersion   string   `json:"api_version"`
+	Operation    string   `json:"operation"`
+	PathParams   []string `json:"path_params"`
+	AuthRequired bool     `json:"auth_required"`
+
+	// Role required of the calling user (member or admin), empty for public routes
+	Role string `json:"role,omitempty"`
+	// Scope a service account key needs, empty when service accounts cannot call the route
+	ServiceAccountScope string `json:"service_account_scope,omitempty"`
+
+	// JSON request body and response envelope, absent for routes registered
+	// without a Router and for non-JSON bodies (payload uploads, file downloads)
+	Request  *Schema `json:"request,omitempty"`
+	Response *Schema `json:"response,omitempty"`
+}
+
+// DescribeRoutes converts registered gin routes into stable, sorted metadata
+func DescribeRoutes(routes gin.RoutesInfo) []RouteInfo {
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		apiVersion := ""
+		if segments := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2); len(segments) > 0 {
+			if segments[0] == "v1" || segments[0] == "v2" {
+				apiVersion = segments[0]
+			}
+		}
+
+		params := make([]string, 0)
+		for _, segment := range strings.Split(route.Path, "/") {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				params = append(params, segment[1:])
+			}
+		}
+
+		info := RouteInfo{
+			Method:     route.Method,
+			Path:       route.Path,
+			APIVersion: apiVersion,
+			Operation:  operationName(route.Handler),
+			PathParams: params,
+		}
+		if registered, ok := lookup(route.Method, route.Path); ok {
+			info.AuthRequired = registered.access.AuthRequired
+			if info.AuthRequired {
+				info.Role = registered.access.Role
+				if info.Role == "" {
+					info.Role = models.RoleMember
+				}
+				if !registered.access.HumanOnly {
+					info.ServiceAccountScope = models.ScopeForMethod(route.Method)
+				}
+			}
+			if registered.spec.Request != nil {
+				info.Request = SchemaOf(registered.spec.Request)
+			}
+			if registered.spec.Response != nil {
+				info.Response = responseSchema(registered.spec.Response)
+			}
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+
+	return infos
+}
+
+// ChangelogSince returns entries newer than the given version ("" returns all)
+func ChangelogSince(version string) []ChangelogEntry {
+	if version == "" {
+		return Changelog
+	}
+
+	entries := make([]ChangelogEntry, 0)
+	for _, entry := range Changelog {
+		if CompareVersions(entry.Version, version) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// CompareVersions compares dotted numeric versions, returning -1, 0 or 1
+func CompareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// operationName extracts the method name from a gin handler name, e.g.
+// "MLQueue/internal/handlers.(*GroupHandler).CreateGroup-fm" -> "CreateGroup"
+func operationName(handler string) string {
+	name := strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,143 @@
+package apimeta
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec documents a route where it is registered
+type Spec struct {
+	// Request is a zero value of the JSON request body type, nil when the route takes no body
+	Request interface{}
+	// Response maps top-level response fields (besides "success") to values of their type,
+	// nil when the route does not respond with a JSON envelope
+	Response gin.H
+}
+
+// Access is the authorization the middleware in front of a route requires
+type Access struct {
+	AuthRequired bool
+	Role         string
+	HumanOnly    bool
+}
+
+// Restriction describes what a guard middleware requires of callers
+type Restriction func(*Access)
+
+// RequireAuth marks routes behind an authentication middleware
+func RequireAuth(a *Access) { a.AuthRequired = true }
+
+// RequireRole marks routes restricted to users with the given role
+func RequireRole(role string) Restriction {
+	return func(a *Access) { a.Role = role }
+}
+
+// RejectServiceAccounts marks routes that service account keys cannot call
+func RejectServiceAccounts(a *Access) { a.HumanOnly = true }
+
+type route struct {
+	spec   Spec
+	access Access
+}
+
+var (
+	mu     sync.RWMutex
+	guards = map[uintptr][]Restriction{}
+	routes = map[string]route{}
+)
+
+// Guard records the restrictions an authorization middleware enforces and
+// returns the middleware unchanged; routes registered behind it through a
+// Router report them in DescribeRoutes
+func Guard(middleware gin.HandlerFunc, restrictions ...Restriction) gin.HandlerFunc {
+	mu.Lock()
+	defer mu.Unlock()
+	guards[handlerKey(middleware)] = restrictions
+	return middleware
+}
+
+// Router registers routes on a gin router group and records their Spec and
+// the Access derived from the guards in their handler chain
+type Router struct {
+	group *gin.RouterGroup
+}
+
+func NewRouter(group *gin.RouterGroup) *Router {
+	return &Router{group: group}
+}
+
+// Use adds middleware to the group, as gin.RouterGroup.Use
+func (r *Router) Use(middleware ...gin.HandlerFunc) {
+	r.group.Use(middleware...)
+}
+
+// Group creates a sub-router, as gin.RouterGroup.Group
+func (r *Router) Group(relativePath string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{group: r.group.Group(relativePath, handlers...)}
+}
+
+// Handle registers a route and records its metadata
+func (r *Router) Handle(method, relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	var access Access
+	mu.Lock()
+	for _, handler := range append(append(gin.HandlersChain{}, r.group.Handlers...), handlers...) {
+		for _, restrict := range guards[handlerKey(handler)] {
+			restrict(&access)
+		}
+	}
+	routes[method+" "+joinPaths(r.group.BasePath(), relativePath)] = route{spec: spec, access: access}
+	mu.Unlock()
+
+	r.group.Handle(method, relativePath, handlers...)
+}
+
+func (r *Router) GET(relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, relativePath, spec, handlers...)
+}
+
+func (r *Router) POST(relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, relativePath, spec, handlers...)
+}
+
+func (r *Router) PUT(relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, relativePath, spec, handlers...)
+}
+
+func (r *Router) PATCH(relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPatch, relativePath, spec, handlers...)
+}
+
+func (r *Router) DELETE(relativePath string, spec Spec, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, relativePath, spec, handlers...)
+}
+
+// lookup returns the metadata recorded for a registered route
+func lookup(method, fullPath string) (route, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	r, ok := routes[method+" "+fullPath]
+	return r, ok
+}
+
+// handlerKey identifies a handler by its code pointer, so every closure
+// returned by the same middleware constructor shares one key
+func handlerKey(handler gin.HandlerFunc) uintptr {
+	return reflect.ValueOf(handler).Pointer()
+}
+
+// joinPaths joins a group base path and a relative path the way gin does
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
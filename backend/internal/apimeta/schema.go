@@ -0,0 +1,123 @@
+package apimeta
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema describes the shape of a JSON value
+type Schema struct {
+	Type     string  `json:"type"` // object, array, string, integer, number, boolean or any
+	Format   string  `json:"format,omitempty"`
+	TypeName string  `json:"type_name,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+	Items    *Schema `json:"items,omitempty"`
+}
+
+type Field struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	Schema
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf derives the JSON schema of a value from its Go type, following
+// json tags; fields with a "required" binding tag are marked required
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{Type: "any"}
+	}
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+// responseSchema describes the response envelope: "success" plus the spec's fields
+func responseSchema(fields map[string]interface{}) *Schema {
+	schema := &Schema{Type: "object", Fields: []Field{
+		{Name: "success", Required: true, Schema: Schema{Type: "boolean"}},
+	}}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema.Fields = append(schema.Fields, Field{Name: name, Schema: *SchemaOf(fields[name])})
+	}
+	return schema
+}
+
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && (t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType)):
+		// e.g. gorm.DeletedAt, serialized as a timestamp or null
+		return &Schema{Type: "string", Format: "date-time", TypeName: t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", TypeName: t.Name()}
+		if visiting[t] {
+			return schema
+		}
+		visiting[t] = true
+		schema.Fields = structFields(t, visiting)
+		delete(visiting, t)
+		return schema
+	}
+	return &Schema{Type: "any"}
+}
+
+func structFields(t reflect.Type, visiting map[reflect.Type]bool) []Field {
+	fields := make([]Field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// embedded structs are flattened, as encoding/json does
+			fields = append(fields, structFields(f.Type, visiting)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, Field{
+			Name:     name,
+			Required: strings.Contains(f.Tag.Get("binding"), "required"),
+			Schema:   *schemaOf(f.Type, visiting),
+		})
+	}
+	return fields
+}
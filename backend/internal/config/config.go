@@ -16,6 +16,11 @@ type Config struct {
 	RateLimit RateLimitConfig
 	Queue     QueueConfig
 	Webhook   WebhookConfig
+	Receipt   ReceiptConfig
+	Trash     TrashConfig
+	SMTP      SMTPConfig
+	Payload   PayloadConfig
+	Snapshot  SnapshotConfig
 }
 
 type ServerConfig struct {
@@ -64,6 +69,34 @@ type WebhookConfig struct {
 	RetryCount     int
 }
 
+type ReceiptConfig struct {
+	SigningKey string // base64 Ed25519 seed
+	KeyFile    string // seed file generated on first start when SigningKey is empty
+}
+
+type TrashConfig struct {
+	RetentionDays int
+}
+
+type PayloadConfig struct {
+	MaxResultBytes  int
+	MaxMetricsBytes int
+	MaxStreamBytes  int64
+	StorageDir      string
+}
+
+type SnapshotConfig struct {
+	IntervalMinutes int
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
 var AppConfig *Config
 
 func Load() *Config {
@@ -112,6 +145,29 @@ func Load() *Config {
 			TimeoutSeconds: getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 30),
 			RetryCount:     getEnvAsInt("WEBHOOK_RETRY_COUNT", 3),
 		},
+		Receipt: ReceiptConfig{
+			SigningKey: getEnv("RECEIPT_SIGNING_KEY", ""),
+			KeyFile:    getEnv("RECEIPT_KEY_FILE", "./data/receipt_signing_key"),
+		},
+		Trash: TrashConfig{
+			RetentionDays: getEnvAsInt("TRASH_RETENTION_DAYS", 7),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "mlqueue@localhost"),
+		},
+		Payload: PayloadConfig{
+			MaxResultBytes:  getEnvAsInt("PAYLOAD_MAX_RESULT_BYTES", 256*1024),
+			MaxMetricsBytes: getEnvAsInt("PAYLOAD_MAX_METRICS_BYTES", 64*1024),
+			MaxStreamBytes:  int64(getEnvAsInt("PAYLOAD_MAX_STREAM_BYTES", 512*1024*1024)),
+			StorageDir:      getEnv("PAYLOAD_STORAGE_DIR", "./data/payloads"),
+		},
+		Snapshot: SnapshotConfig{
+			IntervalMinutes: getEnvAsInt("SNAPSHOT_INTERVAL_MINUTES", 5),
+		},
 	}
 
 	return AppConfig
@@ -38,15 +38,18 @@ func (h *ConfigHandler) GetTemplates(c *gin.Context) {
 	})
 }
 
+// CreateTemplateRequest is the body of POST /v1/configs/templates
+type CreateTemplateRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Config      map[string]interface{} `json:"config" binding:"required"`
+	Description string                 `json:"description"`
+}
+
 // CreateTemplate creates a configuration template
 func (h *ConfigHandler) CreateTemplate(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name        string                 `json:"name" binding:"required"`
-		Config      map[string]interface{} `json:"config" binding:"required"`
-		Description string                 `json:"description"`
-	}
+	var req CreateTemplateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
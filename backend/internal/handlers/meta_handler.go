@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"MLQueue/internal/apimeta"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetaHandler struct {
+	router *gin.Engine
+}
+
+func NewMetaHandler(router *gin.Engine) *MetaHandler {
+	return &MetaHandler{router: router}
+}
+
+// GetRoutes returns metadata for every registered route, for SDK generators
+func (h *MetaHandler) GetRoutes(c *gin.Context) {
+	routes := apimeta.DescribeRoutes(h.router.Routes())
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"protocol_version": apimeta.ProtocolVersion,
+		"routes":           routes,
+		"count":            len(routes),
+	})
+}
+
+// GetChangelog returns the protocol changelog, optionally only entries after ?since=
+func (h *MetaHandler) GetChangelog(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"protocol_version": apimeta.ProtocolVersion,
+		"changelog":        apimeta.ChangelogSince(c.Query("since")),
+	})
+}
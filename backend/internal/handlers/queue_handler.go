@@ -74,13 +74,16 @@ func (h *QueueHandler) GetQueueStatus(c *gin.Context) {
 	})
 }
 
+// ReorderQueueRequest is the body of POST /v1/queue/reorder
+type ReorderQueueRequest struct {
+	TaskIDs []string `json:"task_ids" binding:"required"`
+}
+
 // ReorderQueue manually reorders queue
 func (h *QueueHandler) ReorderQueue(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		TaskIDs []string `json:"task_ids" binding:"required"`
-	}
+	var req ReorderQueueRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -23,16 +23,19 @@ func NewTaskHandler(qm *queue.Manager) *TaskHandler {
 	return &TaskHandler{queueManager: qm}
 }
 
+// CreateTaskRequest is the body of POST /v1/tasks
+type CreateTaskRequest struct {
+	Name     string                 `json:"name" binding:"required"`
+	Config   map[string]interface{} `json:"config" binding:"required"`
+	Priority int                    `json:"priority"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
 // CreateTask creates a new training task
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name     string                 `json:"name" binding:"required"`
-		Config   map[string]interface{} `json:"config" binding:"required"`
-		Priority int                    `json:"priority"`
-		Metadata map[string]interface{} `json:"metadata"`
-	}
+	var req CreateTaskRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -83,17 +86,20 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	})
 }
 
+// BatchCreateTasksRequest is the body of POST /v1/tasks/batch
+type BatchCreateTasksRequest struct {
+	Tasks []struct {
+		Name     string                 `json:"name" binding:"required"`
+		Config   map[string]interface{} `json:"config" binding:"required"`
+		Priority int                    `json:"priority"`
+	} `json:"tasks" binding:"required"`
+}
+
 // BatchCreateTasks creates multiple tasks
 func (h *TaskHandler) BatchCreateTasks(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Tasks []struct {
-			Name     string                 `json:"name" binding:"required"`
-			Config   map[string]interface{} `json:"config" binding:"required"`
-			Priority int                    `json:"priority"`
-		} `json:"tasks" binding:"required"`
-	}
+	var req BatchCreateTasksRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -214,14 +220,17 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 	})
 }
 
+// UpdateTaskPriorityRequest is the body of PATCH /v1/tasks/:task_id/priority
+type UpdateTaskPriorityRequest struct {
+	Priority int `json:"priority" binding:"required"`
+}
+
 // UpdateTaskPriority updates task priority
 func (h *TaskHandler) UpdateTaskPriority(c *gin.Context) {
 	taskID := c.Param("task_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Priority int `json:"priority" binding:"required"`
-	}
+	var req UpdateTaskPriorityRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -271,14 +280,17 @@ func (h *TaskHandler) UpdateTaskPriority(c *gin.Context) {
 	})
 }
 
+// CancelTaskRequest is the body of POST /v1/tasks/:task_id/cancel
+type CancelTaskRequest struct {
+	Reason string `json:"reason"`
+}
+
 // CancelTask cancels a task
 func (h *TaskHandler) CancelTask(c *gin.Context) {
 	taskID := c.Param("task_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Reason string `json:"reason"`
-	}
+	var req CancelTaskRequest
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		return
@@ -322,15 +334,18 @@ func (h *TaskHandler) CancelTask(c *gin.Context) {
 	})
 }
 
+// UploadResultRequest is the body of POST /v1/tasks/:task_id/result
+type UploadResultRequest struct {
+	Result    map[string]interface{} `json:"result" binding:"required"`
+	Artifacts map[string]interface{} `json:"artifacts"`
+}
+
 // UploadResult uploads task result
 func (h *TaskHandler) UploadResult(c *gin.Context) {
 	taskID := c.Param("task_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Result    map[string]interface{} `json:"result" binding:"required"`
-		Artifacts map[string]interface{} `json:"artifacts"`
-	}
+	var req UploadResultRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -370,3 +385,127 @@ func (h *TaskHandler) UploadResult(c *gin.Context) {
 		"status":  task.Status,
 	})
 }
+
+// GetTaskPlacement explains why a task has not started yet
+func (h *TaskHandler) GetTaskPlacement(c *gin.Context) {
+	taskID := c.Param("task_id")
+	userID := middleware.GetUserID(c)
+
+	var task models.Task
+	if err := database.DB.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "任务不存在",
+			"code":    "TASK_NOT_FOUND",
+		})
+		return
+	}
+
+	waiting := task.Status == models.TaskStatusQueued || task.Status == models.TaskStatusPending
+	workers := gin.H{
+		"total": h.queueManager.WorkerCount(),
+		"busy":  h.queueManager.BusyWorkers(),
+	}
+
+	if !waiting {
+		c.JSON(http.StatusOK, gin.H{
+			"success":  true,
+			"task_id":  task.ID,
+			"status":   task.Status,
+			"waiting":  false,
+			"workers":  workers,
+			"blockers": []gin.H{},
+			"summary":  fmt.Sprintf("任务当前状态为%s，不在等待队列中", task.Status),
+		})
+		return
+	}
+
+	blockers := make([]gin.H, 0)
+	paused := h.queueManager.IsPaused()
+	if paused {
+		blockers = append(blockers, gin.H{"code": "QUEUE_PAUSED", "message": "队列已暂停，恢复后才会调度任务"})
+	}
+
+	if h.queueManager.WorkerCount() == 0 {
+		blockers = append(blockers, gin.H{"code": "NO_WORKERS", "message": "没有可用的worker"})
+	} else if h.queueManager.BusyWorkers() >= h.queueManager.WorkerCount() {
+		blockers = append(blockers, gin.H{"code": "WORKERS_BUSY", "message": "所有worker都在处理其他任务"})
+	}
+
+	// Position and tasks ahead come from a single rank lookup, so a task
+	// dequeued in between is reported as not in queue rather than an error
+	position, ahead, err := h.queueManager.GetTasksAhead(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询队列失败",
+			"code":    "INTERNAL_ERROR",
+		})
+		return
+	}
+	queueLength, _ := h.queueManager.GetQueueLength()
+
+	// Tasks ahead: only the caller's own tasks are listed, others are counted
+	ownAhead := make([]gin.H, 0)
+	otherAhead := 0
+	if position < 0 {
+		blockers = append(blockers, gin.H{"code": "NOT_IN_QUEUE", "message": "任务不在调度队列中，请重新提交"})
+	} else {
+		aheadIDs := make([]string, len(ahead))
+		for i, z := range ahead {
+			aheadIDs[i] = z.Member.(string)
+		}
+
+		var ownTasks []models.Task
+		if len(aheadIDs) > 0 {
+			database.DB.Where("id IN ? AND user_id = ?", aheadIDs, userID).Find(&ownTasks)
+		}
+		ownByID := make(map[string]models.Task, len(ownTasks))
+		for _, t := range ownTasks {
+			ownByID[t.ID] = t
+		}
+
+		for _, id := range aheadIDs {
+			if t, ok := ownByID[id]; ok {
+				ownAhead = append(ownAhead, gin.H{
+					"task_id":  t.ID,
+					"name":     t.Name,
+					"priority": t.Priority,
+				})
+			} else {
+				otherAhead++
+			}
+		}
+
+		if len(aheadIDs) > 0 {
+			blockers = append(blockers, gin.H{
+				"code":    "TASKS_AHEAD",
+				"message": fmt.Sprintf("前面还有%d个优先级更高或更早提交的任务", len(aheadIDs)),
+			})
+		}
+	}
+
+	summary := "任务即将被调度"
+	if len(blockers) > 0 {
+		summary = blockers[0]["message"].(string)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"task_id":      task.ID,
+		"status":       task.Status,
+		"waiting":      true,
+		"priority":     task.Priority,
+		"position":     position,
+		"queue_length": queueLength,
+		"paused":       paused,
+		"workers":      workers,
+		"ahead": gin.H{
+			"total":       len(ownAhead) + otherAhead,
+			"own_tasks":   ownAhead,
+			"other_tasks": otherAhead,
+		},
+		"blockers": blockers,
+		"summary":  summary,
+	})
+}
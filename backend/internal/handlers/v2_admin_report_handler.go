@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminReportHandler struct{}
+
+func NewAdminReportHandler() *AdminReportHandler {
+	return &AdminReportHandler{}
+}
+
+// GetOverview 组织整体汇总：活跃单元、运行中/待执行队列、失败率、GPU时长
+func (h *AdminReportHandler) GetOverview(c *gin.Context) {
+	since, ok := reportSince(c, 30)
+	if !ok {
+		return
+	}
+
+	members, err := services.CollectMemberUsage(since)
+	if err != nil {
+		reportFailed(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"overview": services.SummarizeUsage(members, since),
+	})
+}
+
+// ListMembers 按成员汇总资源使用（支持format=csv导出）
+func (h *AdminReportHandler) ListMembers(c *gin.Context) {
+	since, ok := reportSince(c, 30)
+	if !ok {
+		return
+	}
+
+	members, err := services.CollectMemberUsage(since)
+	if err != nil {
+		reportFailed(c)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeUsageCSV(c, "members", members)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"since":   since,
+		"members": members,
+	})
+}
+
+// GetTopConsumers 按GPU时长排序的资源消耗排行（支持format=csv导出）
+func (h *AdminReportHandler) GetTopConsumers(c *gin.Context) {
+	since, ok := reportSince(c, 30)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的limit参数",
+		})
+		return
+	}
+
+	members, err := services.CollectMemberUsage(since)
+	if err != nil {
+		reportFailed(c)
+		return
+	}
+
+	consumers := services.TopConsumers(members, limit)
+
+	if c.Query("format") == "csv" {
+		writeUsageCSV(c, "top_consumers", consumers)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"since":     since,
+		"consumers": consumers,
+	})
+}
+
+// GetStaleResources 长时间无心跳的训练单元及长时间未执行的队列（支持format=csv导出）
+func (h *AdminReportHandler) GetStaleResources(c *gin.Context) {
+	cutoff, ok := reportSince(c, 14)
+	if !ok {
+		return
+	}
+
+	units, queues, err := services.FindStaleResources(cutoff)
+	if err != nil {
+		reportFailed(c)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"resource", "id", "name", "user_id", "unit_id", "last_heartbeat", "pending_queues", "created_at"}}
+		for _, u := range units {
+			lastHeartbeat := ""
+			if u.LastHeartbeat != nil {
+				lastHeartbeat = u.LastHeartbeat.Format(time.RFC3339)
+			}
+			rows = append(rows, []string{
+				"unit", u.UnitID, u.Name, u.UserID, u.UnitID, lastHeartbeat,
+				strconv.FormatInt(u.PendingQueues, 10), u.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		for _, q := range queues {
+			rows = append(rows, []string{
+				"queue", q.QueueID, q.Name, q.UserID, q.UnitID, "", "", q.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		writeCSV(c, "stale_resources", rows)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"cutoff":  cutoff,
+		"units":   units,
+		"queues":  queues,
+	})
+}
+
+// reportSince 解析days参数（统计最近N天），返回统计起始时间
+func reportSince(c *gin.Context, defaultDays int) (time.Time, bool) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultDays)))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的days参数",
+		})
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -days), true
+}
+
+func reportFailed(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   "生成报表失败",
+	})
+}
+
+func writeUsageCSV(c *gin.Context, name string, members []services.MemberUsage) {
+	rows := [][]string{{
+		"user_id", "name", "email", "units", "active_units", "running_queues", "pending_queues",
+		"completed_queues", "failed_queues", "failure_rate", "gpu_hours",
+	}}
+	for _, m := range members {
+		rows = append(rows, []string{
+			m.UserID, m.Name, m.Email,
+			strconv.FormatInt(m.Units, 10),
+			strconv.FormatInt(m.ActiveUnits, 10),
+			strconv.FormatInt(m.RunningQueues, 10),
+			strconv.FormatInt(m.PendingQueues, 10),
+			strconv.FormatInt(m.CompletedQueues, 10),
+			strconv.FormatInt(m.FailedQueues, 10),
+			strconv.FormatFloat(m.FailureRate, 'f', 4, 64),
+			strconv.FormatFloat(m.GPUHours, 'f', 2, 64),
+		})
+	}
+	writeCSV(c, name, rows)
+}
+
+// writeCSV 以附件形式输出CSV报表
+func writeCSV(c *gin.Context, name string, rows [][]string) {
+	filename := fmt.Sprintf("%s_%s.csv", name, time.Now().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.WriteAll(rows)
+}
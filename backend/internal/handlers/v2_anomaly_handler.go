@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AnomalyHandler struct{}
+
+func NewAnomalyHandler() *AnomalyHandler {
+	return &AnomalyHandler{}
+}
+
+// CreateAnomalyRuleRequest 创建异常检测规则请求体
+type CreateAnomalyRuleRequest struct {
+	Type      string   `json:"type" binding:"required"`
+	Metric    string   `json:"metric"`
+	Patience  int      `json:"patience"`
+	Mode      string   `json:"mode"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	Notify    *bool    `json:"notify"`
+	EarlyStop bool     `json:"early_stop"`
+	Enabled   *bool    `json:"enabled"`
+}
+
+// CreateAnomalyRule 为训练单元创建指标异常检测规则
+func (h *AnomalyHandler) CreateAnomalyRule(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var req CreateAnomalyRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	// 验证训练单元存在
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	// 按规则类型校验参数
+	switch req.Type {
+	case models.AnomalyRuleNaN:
+	case models.AnomalyRuleNoImprovement:
+		if req.Mode == "" {
+			req.Mode = "min"
+		}
+		if req.Metric == "" || req.Patience <= 0 || (req.Mode != "min" && req.Mode != "max") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "no_improvement规则需要metric、大于0的patience以及min/max模式",
+			})
+			return
+		}
+	case models.AnomalyRuleBounds:
+		if req.Metric == "" || (req.Min == nil && req.Max == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "bounds规则需要metric以及min或max",
+			})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "不支持的规则类型",
+		})
+		return
+	}
+
+	rule := models.AnomalyRule{
+		ID:        "rule_" + uuid.New().String()[:8],
+		UnitID:    unitID,
+		Type:      req.Type,
+		Metric:    req.Metric,
+		Patience:  req.Patience,
+		Mode:      req.Mode,
+		Min:       req.Min,
+		Max:       req.Max,
+		Notify:    req.Notify == nil || *req.Notify,
+		EarlyStop: req.EarlyStop,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		UserID:    userID,
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建异常检测规则失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"rule":    rule,
+	})
+}
+
+// ListAnomalyRules 列出训练单元的异常检测规则
+func (h *AnomalyHandler) ListAnomalyRules(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var rules []models.AnomalyRule
+	if err := database.DB.Where("unit_id = ? AND user_id = ?", unitID, userID).
+		Order("created_at ASC").
+		Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询异常检测规则失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rules":   rules,
+	})
+}
+
+// DeleteAnomalyRule 删除异常检测规则
+func (h *AnomalyHandler) DeleteAnomalyRule(c *gin.Context) {
+	ruleID := c.Param("rule_id")
+	userID := middleware.GetUserID(c)
+
+	result := database.DB.Where("id = ? AND user_id = ?", ruleID, userID).
+		Delete(&models.AnomalyRule{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除异常检测规则失败",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "异常检测规则不存在",
+		})
+		return
+	}
+
+	database.DB.Where("rule_id = ?", ruleID).Delete(&models.AnomalyRuleState{})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "异常检测规则已删除",
+	})
+}
+
+// ListQueueAnomalies 列出队列触发的异常记录
+func (h *AnomalyHandler) ListQueueAnomalies(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var events []models.AnomalyEvent
+	if err := database.DB.Where("queue_id = ? AND user_id = ?", queueID, userID).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询异常记录失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"anomalies": events,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"MLQueue/internal/database"
 	"MLQueue/internal/middleware"
 	"MLQueue/internal/models"
+	"MLQueue/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,14 +18,17 @@ func NewGroupHandler() *GroupHandler {
 	return &GroupHandler{}
 }
 
+// CreateGroupRequest 创建组请求体
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
 // CreateGroup 创建组（由Python客户端调用）
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-	}
+	var req CreateGroupRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -105,15 +109,18 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 	})
 }
 
+// UpdateGroupRequest 更新组请求体
+type UpdateGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
 // UpdateGroup 更新组信息
 func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 	groupID := c.Param("group_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	}
+	var req UpdateGroupRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -157,19 +164,30 @@ func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 	groupID := c.Param("group_id")
 	userID := middleware.GetUserID(c)
 
-	// 检查是否有训练单元
-	var count int64
-	database.DB.Model(&models.TrainingUnit{}).
-		Where("group_id = ?", groupID).
-		Count(&count)
-
-	//if count > 0 {
-	//	c.JSON(http.StatusBadRequest, gin.H{
-	//		"success": false,
-	//		"error":   "组内还有训练单元，无法删除",
-	//	})
-	//	return
-	//}
+	// 组内训练单元（含回收站中的）随组永久删除，先清理队列payload文件及关联记录
+	var unitIDs []string
+	database.DB.Unscoped().
+		Model(&models.TrainingUnit{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Pluck("id", &unitIDs)
+
+	if _, _, err := services.PurgeUnits(unitIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除组内训练单元失败",
+		})
+		return
+	}
+
+	// 组级通知路由随组删除
+	if err := database.DB.Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&models.NotificationRoute{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除组通知路由失败",
+		})
+		return
+	}
 
 	if err := database.DB.Where("id = ? AND user_id = ?", groupID, userID).
 		Delete(&models.Group{}).Error; err != nil {
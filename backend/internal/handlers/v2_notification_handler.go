@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct{}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{}
+}
+
+// NotificationRouteRequest 通知路由请求体（组级与单元级共用）
+type NotificationRouteRequest struct {
+	WebhookURL      string   `json:"webhook_url"`
+	SlackWebhookURL string   `json:"slack_webhook_url"`
+	SlackChannel    string   `json:"slack_channel"`
+	EmailTo         []string `json:"email_to"`
+	Events          []string `json:"events"`
+	Muted           *bool    `json:"muted"` // 仅单元级覆盖支持
+}
+
+// validate 校验请求内容，返回错误描述
+func (req *NotificationRouteRequest) validate() string {
+	for _, addr := range req.EmailTo {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return "无效的邮箱地址: " + addr
+		}
+	}
+	return ""
+}
+
+// apply 将请求内容写入路由配置
+func (req *NotificationRouteRequest) apply(route *models.NotificationRoute) {
+	route.WebhookURL = req.WebhookURL
+	route.SlackWebhookURL = req.SlackWebhookURL
+	route.SlackChannel = req.SlackChannel
+	route.EmailTo = joinEmails(req.EmailTo)
+	route.Events = nil
+	if req.Events != nil {
+		events := make([]interface{}, len(req.Events))
+		for i, e := range req.Events {
+			events[i] = e
+		}
+		route.Events = models.JSONB{"events": events}
+	}
+}
+
+// SetGroupNotifications 设置组级默认通知路由（组内所有训练单元继承）
+func (h *NotificationHandler) SetGroupNotifications(c *gin.Context) {
+	groupID := c.Param("group_id")
+	userID := middleware.GetUserID(c)
+
+	var req NotificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	// 静默仅在单元级覆盖中生效
+	if req.Muted != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "组级通知路由不支持muted，请在训练单元上设置",
+		})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   msg,
+		})
+		return
+	}
+
+	// 验证组存在
+	var group models.Group
+	if err := database.DB.Where("id = ? AND user_id = ?", groupID, userID).
+		First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "组不存在",
+		})
+		return
+	}
+
+	route := models.NotificationRoute{GroupID: groupID, UserID: userID}
+	if err := loadExistingRoute(&route, "group_id = ? AND unit_id = ?", groupID, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询通知路由失败",
+		})
+		return
+	}
+
+	req.apply(&route)
+
+	if err := database.DB.Save(&route).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "保存通知路由失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"notifications": route,
+	})
+}
+
+// GetGroupNotifications 获取组级默认通知路由
+func (h *NotificationHandler) GetGroupNotifications(c *gin.Context) {
+	groupID := c.Param("group_id")
+	userID := middleware.GetUserID(c)
+
+	var route models.NotificationRoute
+	if err := database.DB.Where("group_id = ? AND unit_id = ? AND user_id = ?", groupID, "", userID).
+		First(&route).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "组未配置通知路由",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"notifications": route,
+	})
+}
+
+// DeleteGroupNotifications 删除组级默认通知路由
+func (h *NotificationHandler) DeleteGroupNotifications(c *gin.Context) {
+	groupID := c.Param("group_id")
+	userID := middleware.GetUserID(c)
+
+	if err := database.DB.Where("group_id = ? AND unit_id = ? AND user_id = ?", groupID, "", userID).
+		Delete(&models.NotificationRoute{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除通知路由失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "通知路由已删除",
+	})
+}
+
+// SetUnitNotifications 设置训练单元的通知覆盖（非空渠道覆盖组级配置，muted静默全部通知）
+func (h *NotificationHandler) SetUnitNotifications(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var req NotificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   msg,
+		})
+		return
+	}
+
+	// 验证训练单元存在
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	route := models.NotificationRoute{GroupID: unit.GroupID, UnitID: unitID, UserID: userID}
+	if err := loadExistingRoute(&route, "unit_id = ?", unitID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询通知路由失败",
+		})
+		return
+	}
+
+	req.apply(&route)
+	route.Muted = req.Muted != nil && *req.Muted
+
+	if err := database.DB.Save(&route).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "保存通知路由失败",
+		})
+		return
+	}
+
+	effective, _ := services.ResolveRoute(&unit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"notifications": route,
+		"effective":     effective,
+	})
+}
+
+// GetUnitNotifications 获取训练单元的通知覆盖及合并组级配置后的生效路由
+func (h *NotificationHandler) GetUnitNotifications(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	var override *models.NotificationRoute
+	var route models.NotificationRoute
+	if err := database.DB.Where("unit_id = ?", unitID).First(&route).Error; err == nil {
+		override = &route
+	}
+
+	effective, err := services.ResolveRoute(&unit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询通知路由失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"notifications": override,
+		"effective":     effective,
+	})
+}
+
+// DeleteUnitNotifications 删除训练单元的通知覆盖（恢复继承组级配置）
+func (h *NotificationHandler) DeleteUnitNotifications(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	if err := database.DB.Where("unit_id = ? AND user_id = ?", unitID, userID).
+		Delete(&models.NotificationRoute{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除通知路由失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "训练单元将继承组级通知路由",
+	})
+}
+
+// loadExistingRoute 加载已有路由配置，不存在时保留route中的初始值
+func loadExistingRoute(route *models.NotificationRoute, query string, args ...interface{}) error {
+	var existing models.NotificationRoute
+	err := database.DB.Where(query, args...).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*route = existing
+	return nil
+}
+
+// joinEmails 规范化邮箱地址（去除显示名）并以逗号连接，调用前需已通过validate
+func joinEmails(values []string) string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if addr, err := mail.ParseAddress(strings.TrimSpace(v)); err == nil {
+			result = append(result, addr.Address)
+		}
+	}
+	return strings.Join(result, ",")
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"MLQueue/internal/config"
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayloadHandler struct {
+	store *services.PayloadStore
+}
+
+func NewPayloadHandler() *PayloadHandler {
+	return &PayloadHandler{store: services.NewPayloadStore()}
+}
+
+// UploadPayload 流式上传超出内联限制的result/metrics（支持chunked传输）
+// 上传成功后队列对应字段替换为payload引用，同类旧上传随之删除
+func (h *PayloadHandler) UploadPayload(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	kind := c.Param("kind")
+	userID := middleware.GetUserID(c)
+
+	if kind != "result" && kind != "metrics" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "kind只能是result或metrics",
+		})
+		return
+	}
+
+	var queue models.TrainingQueue
+	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练队列不存在",
+		})
+		return
+	}
+
+	if queue.Status == "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "队列尚未开始，无法上传结果",
+		})
+		return
+	}
+
+	maxBytes := config.AppConfig.Payload.MaxStreamBytes
+	if c.Request.ContentLength > maxBytes {
+		payloadTooLarge(c, kind, c.Request.ContentLength, maxBytes, "")
+		return
+	}
+
+	blob, err := h.store.Save(&queue, kind, c.Request.Body)
+	if errors.Is(err, services.ErrPayloadTooLarge) {
+		payloadTooLarge(c, kind, maxBytes+1, maxBytes, "")
+		return
+	}
+	if errors.Is(err, services.ErrPayloadInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "上传内容必须是JSON对象",
+			"code":    "INVALID_PAYLOAD",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to store payload for queue %s: %v", queue.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "保存上传内容失败",
+		})
+		return
+	}
+
+	reference := services.PayloadReference(blob)
+	if err := database.DB.Model(&queue).Update(kind, reference).Error; err != nil {
+		// 队列仍指向旧内容，删除本次上传避免留下无引用的文件
+		if err := h.store.Remove(blob); err != nil {
+			log.Printf("Failed to remove payload %s: %v", blob.ID, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "更新队列失败",
+		})
+		return
+	}
+
+	// 重新上传替换旧内容，旧文件不再可下载
+	if err := h.store.RemoveSuperseded(queue.ID, kind, blob.ID); err != nil {
+		log.Printf("Failed to remove superseded payloads for queue %s: %v", queue.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"payload":   blob,
+		"reference": reference,
+	})
+}
+
+// GetPayload 下载payload原始内容
+func (h *PayloadHandler) GetPayload(c *gin.Context) {
+	payloadID := c.Param("payload_id")
+	userID := middleware.GetUserID(c)
+
+	var blob models.PayloadBlob
+	if err := database.DB.Where("id = ? AND user_id = ?", payloadID, userID).
+		First(&blob).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "上传内容不存在",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("X-Payload-SHA256", blob.SHA256)
+	c.File(blob.StoragePath)
+}
+
+// ListQueuePayloads 列出队列的payload上传记录
+func (h *PayloadHandler) ListQueuePayloads(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var blobs []models.PayloadBlob
+	if err := database.DB.Where("queue_id = ? AND user_id = ?", queueID, userID).
+		Order("created_at DESC").
+		Find(&blobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询上传内容失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"payloads": blobs,
+	})
+}
+
+// rejectOversized 检查内联JSON字段大小，超出限制时返回413并提示使用流式上传
+func rejectOversized(c *gin.Context, field string, data map[string]interface{}, limit int, hint string) bool {
+	size := services.JSONSize(data)
+	if size <= limit {
+		return false
+	}
+	payloadTooLarge(c, field, int64(size), int64(limit), hint)
+	return true
+}
+
+func payloadTooLarge(c *gin.Context, field string, size, limit int64, hint string) {
+	message := fmt.Sprintf("%s 大小 %d 字节超过上限 %d 字节", field, size, limit)
+	if hint != "" {
+		message += "，" + hint
+	}
+
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"success":     false,
+		"error":       message,
+		"code":        "PAYLOAD_TOO_LARGE",
+		"field":       field,
+		"size_bytes":  size,
+		"limit_bytes": limit,
+	})
+}
@@ -1,15 +1,21 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"MLQueue/internal/config"
 	"MLQueue/internal/database"
 	"MLQueue/internal/middleware"
 	"MLQueue/internal/models"
+	"MLQueue/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type QueueHandlerV2 struct{}
@@ -18,16 +24,19 @@ func NewQueueHandlerV2() *QueueHandlerV2 {
 	return &QueueHandlerV2{}
 }
 
+// CreateQueueRequest 创建训练队列请求体
+type CreateQueueRequest struct {
+	Name       string                 `json:"name" binding:"required"`
+	Parameters map[string]interface{} `json:"parameters" binding:"required"`
+	CreatedBy  string                 `json:"created_by"` // 'client' or 'web'
+}
+
 // CreateTrainingQueue 创建训练队列（Python客户端或前端）
 func (h *QueueHandlerV2) CreateTrainingQueue(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name       string                 `json:"name" binding:"required"`
-		Parameters map[string]interface{} `json:"parameters" binding:"required"`
-		CreatedBy  string                 `json:"created_by"` // 'client' or 'web'
-	}
+	var req CreateQueueRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -71,6 +80,7 @@ func (h *QueueHandlerV2) CreateTrainingQueue(c *gin.Context) {
 		Order:      newOrder,
 		Status:     "pending",
 		CreatedBy:  createdBy,
+		ActorID:    middleware.GetActorID(c),
 		UserID:     userID,
 	}
 
@@ -92,18 +102,21 @@ func (h *QueueHandlerV2) CreateTrainingQueue(c *gin.Context) {
 	})
 }
 
+// BatchCreateQueuesRequest 批量创建训练队列请求体
+type BatchCreateQueuesRequest struct {
+	Queues []struct {
+		Name       string                 `json:"name" binding:"required"`
+		Parameters map[string]interface{} `json:"parameters" binding:"required"`
+	} `json:"queues" binding:"required"`
+	CreatedBy string `json:"created_by"`
+}
+
 // BatchCreateQueues 批量创建训练队列（用于超参数搜索）
 func (h *QueueHandlerV2) BatchCreateQueues(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Queues []struct {
-			Name       string                 `json:"name" binding:"required"`
-			Parameters map[string]interface{} `json:"parameters" binding:"required"`
-		} `json:"queues" binding:"required"`
-		CreatedBy string `json:"created_by"`
-	}
+	var req BatchCreateQueuesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -147,6 +160,7 @@ func (h *QueueHandlerV2) BatchCreateQueues(c *gin.Context) {
 			Order:      maxOrder + 1 + i,
 			Status:     "pending",
 			CreatedBy:  createdBy,
+			ActorID:    middleware.GetActorID(c),
 			UserID:     userID,
 		}
 
@@ -228,15 +242,18 @@ func (h *QueueHandlerV2) GetTrainingQueue(c *gin.Context) {
 	})
 }
 
+// UpdateQueueRequest 更新训练队列请求体
+type UpdateQueueRequest struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
 // UpdateTrainingQueue 更新队列参数（仅前端，不能修改运行中的）
 func (h *QueueHandlerV2) UpdateTrainingQueue(c *gin.Context) {
 	queueID := c.Param("queue_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name       string                 `json:"name"`
-		Parameters map[string]interface{} `json:"parameters"`
-	}
+	var req UpdateQueueRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -300,7 +317,7 @@ func (h *QueueHandlerV2) UpdateTrainingQueue(c *gin.Context) {
 	})
 }
 
-// DeleteTrainingQueue 删除队列
+// DeleteTrainingQueue 删除队列（移入回收站，保留期内可恢复）
 func (h *QueueHandlerV2) DeleteTrainingQueue(c *gin.Context) {
 	queueID := c.Param("queue_id")
 	userID := middleware.GetUserID(c)
@@ -340,8 +357,140 @@ func (h *QueueHandlerV2) DeleteTrainingQueue(c *gin.Context) {
 		Update("version", database.DB.Raw("version + 1"))
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "队列已删除",
+		"success":    true,
+		"message":    "队列已移入回收站",
+		"expires_at": time.Now().Add(services.TrashRetention()),
+	})
+}
+
+// BatchDeleteQueuesRequest 批量删除训练队列请求体
+type BatchDeleteQueuesRequest struct {
+	QueueIDs []string `json:"queue_ids" binding:"required"`
+}
+
+// BatchDeleteQueues 批量删除队列（移入回收站，保留期内可恢复）
+func (h *QueueHandlerV2) BatchDeleteQueues(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var req BatchDeleteQueuesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	// 验证训练单元存在
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	// 去重，避免重复ID导致数量校验不一致
+	queueIDs := make([]string, 0, len(req.QueueIDs))
+	seen := make(map[string]bool, len(req.QueueIDs))
+	for _, id := range req.QueueIDs {
+		if !seen[id] {
+			seen[id] = true
+			queueIDs = append(queueIDs, id)
+		}
+	}
+
+	var queues []models.TrainingQueue
+	if err := database.DB.Where("id IN ? AND user_id = ?", queueIDs, userID).
+		Find(&queues).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询队列失败",
+		})
+		return
+	}
+
+	// 不存在或不属于当前用户的队列返回404
+	found := make(map[string]bool, len(queues))
+	for _, queue := range queues {
+		found[queue.ID] = true
+	}
+	missing := make([]string, 0)
+	for _, id := range queueIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success":   false,
+			"error":     "部分队列不存在",
+			"queue_ids": missing,
+		})
+		return
+	}
+
+	// 验证所有队列都属于该训练单元且不在运行中
+	for _, queue := range queues {
+		if queue.UnitID != unitID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "部分队列不属于该训练单元",
+			})
+			return
+		}
+		if queue.Status == "running" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无法删除运行中的队列",
+			})
+			return
+		}
+	}
+
+	// 更新时再次排除运行中的队列，防止检查后队列被启动
+	now := time.Now()
+	errQueueStarted := errors.New("queue started")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TrainingQueue{}).
+			Where("id IN ? AND unit_id = ? AND status <> ?", queueIDs, unitID, "running").
+			Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(queueIDs)) {
+			return errQueueStarted
+		}
+
+		// 更新训练单元版本号
+		return tx.Model(&models.TrainingUnit{}).
+			Where("id = ?", unitID).
+			Update("version", gorm.Expr("version + 1")).Error
+	})
+	if errors.Is(err, errQueueStarted) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "部分队列已开始运行，未删除任何队列",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除队列失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "队列已移入回收站",
+		"deleted_count": len(queueIDs),
+		"expires_at":    now.Add(services.TrashRetention()),
 	})
 }
 
@@ -387,21 +536,26 @@ func (h *QueueHandlerV2) StartQueue(c *gin.Context) {
 		Where("id = ?", queue.UnitID).
 		Update("status", "running")
 
+	services.NewNotificationService().SendQueueStarted(&queue)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"queue":   queue,
 	})
 }
 
+// CompleteQueueRequest 完成队列请求体（字段缺省或为空时保留已有内容）
+type CompleteQueueRequest struct {
+	Result  map[string]interface{} `json:"result"`
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
 // CompleteQueue Python客户端标记队列完成
 func (h *QueueHandlerV2) CompleteQueue(c *gin.Context) {
 	queueID := c.Param("queue_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Result  map[string]interface{} `json:"result"`
-		Metrics map[string]interface{} `json:"metrics"`
-	}
+	var req CompleteQueueRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -411,6 +565,13 @@ func (h *QueueHandlerV2) CompleteQueue(c *gin.Context) {
 		return
 	}
 
+	// 限制内联大小，超大内容需通过payload接口流式上传
+	payloadCfg := config.AppConfig.Payload
+	if rejectOversized(c, "result", req.Result, payloadCfg.MaxResultBytes, "请使用 PUT /v2/queues/"+queueID+"/payloads/result 流式上传") ||
+		rejectOversized(c, "metrics", req.Metrics, payloadCfg.MaxMetricsBytes, "请使用 PUT /v2/queues/"+queueID+"/payloads/metrics 流式上传") {
+		return
+	}
+
 	var queue models.TrainingQueue
 	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
 		First(&queue).Error; err != nil {
@@ -421,11 +582,34 @@ func (h *QueueHandlerV2) CompleteQueue(c *gin.Context) {
 		return
 	}
 
+	// 已流式上传的字段不能再被内联内容覆盖
+	for field, inline := range map[string]map[string]interface{}{"result": req.Result, "metrics": req.Metrics} {
+		existing := queue.Result
+		if field == "metrics" {
+			existing = queue.Metrics
+		}
+		if len(inline) > 0 && services.IsPayloadReference(existing) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   field + " 已通过payload接口上传，不能再内联提交",
+				"code":    "PAYLOAD_ALREADY_UPLOADED",
+				"field":   field,
+			})
+			return
+		}
+	}
+
 	now := time.Now()
 	queue.Status = "completed"
 	queue.CompletedAt = &now
-	queue.Result = models.JSONB(req.Result)
-	queue.Metrics = models.JSONB(req.Metrics)
+
+	// 未提供或为空对象时保留已有内容（如流式上传的payload引用或过程中上报的指标）
+	if len(req.Result) > 0 {
+		queue.Result = models.JSONB(req.Result)
+	}
+	if len(req.Metrics) > 0 {
+		queue.Metrics = models.JSONB(req.Metrics)
+	}
 
 	if err := database.DB.Save(&queue).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -435,20 +619,36 @@ func (h *QueueHandlerV2) CompleteQueue(c *gin.Context) {
 		return
 	}
 
+	if err := services.ExpireCommands(queue.ID); err != nil {
+		log.Printf("Failed to expire commands for queue %s: %v", queue.ID, err)
+	}
+
+	services.NewNotificationService().SendQueueCompleted(&queue)
+
+	// 生成签名执行回执（失败不影响完成状态）
+	receipt, err := services.NewReceiptService().IssueReceipt(&queue)
+	if err != nil {
+		log.Printf("Failed to issue receipt for queue %s: %v", queue.ID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"queue":   queue,
+		"receipt": receipt,
 	})
 }
 
+// FailQueueRequest 队列失败请求体
+type FailQueueRequest struct {
+	ErrorMsg string `json:"error_msg"`
+}
+
 // FailQueue Python客户端标记队列失败
 func (h *QueueHandlerV2) FailQueue(c *gin.Context) {
 	queueID := c.Param("queue_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		ErrorMsg string `json:"error_msg"`
-	}
+	var req FailQueueRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -481,21 +681,327 @@ func (h *QueueHandlerV2) FailQueue(c *gin.Context) {
 		return
 	}
 
+	if err := services.ExpireCommands(queue.ID); err != nil {
+		log.Printf("Failed to expire commands for queue %s: %v", queue.ID, err)
+	}
+
+	services.NewNotificationService().SendQueueFailed(&queue)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"queue":   queue,
 	})
 }
 
+// ReportMetricsRequest 指标上报请求体（NaN/Inf以字符串上报）
+type ReportMetricsRequest struct {
+	Step    int                    `json:"step"`
+	Metrics map[string]interface{} `json:"metrics" binding:"required"`
+}
+
+// ReportMetrics Python客户端在训练过程中上报指标（触发异常检测规则）
+func (h *QueueHandlerV2) ReportMetrics(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var req ReportMetricsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	var queue models.TrainingQueue
+	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练队列不存在",
+		})
+		return
+	}
+
+	if queue.Status != "running" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "只能为运行中的队列上报指标",
+		})
+		return
+	}
+
+	if rejectOversized(c, "metrics", req.Metrics, config.AppConfig.Payload.MaxMetricsBytes, "") {
+		return
+	}
+
+	report := models.MetricReport{
+		QueueID: queue.ID,
+		Step:    req.Step,
+		Metrics: models.JSONB(req.Metrics),
+	}
+
+	if err := database.DB.Create(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "保存指标失败",
+		})
+		return
+	}
+
+	// 队列上保留最新一次指标（已流式上传的metrics不覆盖）
+	if !services.IsPayloadReference(queue.Metrics) {
+		database.DB.Model(&queue).Update("metrics", report.Metrics)
+	}
+
+	anomalies, err := services.NewAnomalyService().Evaluate(&queue, &report)
+	if err != nil {
+		log.Printf("Failed to evaluate anomaly rules for queue %s: %v", queue.ID, err)
+	}
+
+	// 返回待处理命令，客户端据此决定是否提前停止
+	var commands []models.QueueCommand
+	database.DB.Where("queue_id = ? AND status = ?", queue.ID, "pending").
+		Order("created_at ASC").
+		Find(&commands)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"anomaly_detected": queue.AnomalyDetected,
+		"anomalies":        anomalies,
+		"commands":         commands,
+	})
+}
+
+// StopQueueRequest 请求停止队列请求体
+type StopQueueRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RequestStop 请求Python客户端提前停止运行中的队列
+func (h *QueueHandlerV2) RequestStop(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var req StopQueueRequest
+	_ = c.ShouldBindJSON(&req)
+
+	var queue models.TrainingQueue
+	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练队列不存在",
+		})
+		return
+	}
+
+	if queue.Status != "running" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "只能停止运行中的队列",
+		})
+		return
+	}
+
+	command, err := services.IssueCommand(&queue, "stop", req.Reason, middleware.GetActorID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "下发停止命令失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"command": command,
+	})
+}
+
+// ListQueueCommands 列出队列的命令（默认仅pending）
+func (h *QueueHandlerV2) ListQueueCommands(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	status := c.DefaultQuery("status", "pending")
+
+	query := database.DB.Where("queue_id = ? AND user_id = ?", queueID, userID)
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var commands []models.QueueCommand
+	if err := query.Order("created_at ASC").
+		Find(&commands).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询命令失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"commands": commands,
+	})
+}
+
+// AcknowledgeCommand Python客户端确认已处理命令
+func (h *QueueHandlerV2) AcknowledgeCommand(c *gin.Context) {
+	commandID := c.Param("command_id")
+	userID := middleware.GetUserID(c)
+
+	var command models.QueueCommand
+	if err := database.DB.Where("id = ? AND user_id = ?", commandID, userID).
+		First(&command).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "命令不存在",
+		})
+		return
+	}
+
+	if command.Status == "pending" {
+		now := time.Now()
+		command.Status = "acknowledged"
+		command.AcknowledgedAt = &now
+
+		if err := database.DB.Save(&command).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "更新命令状态失败",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"command": command,
+	})
+}
+
+// GetQueuePlacement 解释队列为何尚未开始执行（位置、前序队列、客户端连接状态）
+func (h *QueueHandlerV2) GetQueuePlacement(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var queue models.TrainingQueue
+	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练队列不存在",
+		})
+		return
+	}
+
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ?", queue.UnitID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	// 检查并更新连接状态
+	checkConnectionStatus(&unit)
+
+	if queue.Status != "pending" {
+		c.JSON(http.StatusOK, gin.H{
+			"success":           true,
+			"queue_id":          queue.ID,
+			"status":            queue.Status,
+			"waiting":           false,
+			"connection_status": unit.ConnectionStatus,
+			"blockers":          []gin.H{},
+			"summary":           "队列当前状态为" + queue.Status + "，不在等待执行",
+		})
+		return
+	}
+
+	// Python客户端按order顺序逐个执行pending队列
+	var ahead []models.TrainingQueue
+	database.DB.Where("unit_id = ? AND status = ? AND id <> ?", unit.ID, "pending", queue.ID).
+		Where("\"order\" < ? OR (\"order\" = ? AND created_at < ?)", queue.Order, queue.Order, queue.CreatedAt).
+		Order("\"order\" ASC").
+		Find(&ahead)
+
+	var running []models.TrainingQueue
+	database.DB.Where("unit_id = ? AND status = ?", unit.ID, "running").
+		Find(&running)
+
+	blockers := make([]gin.H, 0)
+	if unit.ConnectionStatus != "connected" {
+		blockers = append(blockers, gin.H{
+			"code":    "CLIENT_DISCONNECTED",
+			"message": "训练单元没有已连接的Python客户端，队列无法被执行",
+		})
+	}
+	if len(running) > 0 {
+		blockers = append(blockers, gin.H{
+			"code":    "UNIT_BUSY",
+			"message": "训练单元正在执行队列 " + running[0].ID,
+		})
+	}
+	if len(ahead) > 0 {
+		blockers = append(blockers, gin.H{
+			"code":    "QUEUES_AHEAD",
+			"message": fmt.Sprintf("前面还有%d个待执行的队列", len(ahead)),
+		})
+	}
+
+	aheadList := make([]gin.H, len(ahead))
+	for i, q := range ahead {
+		aheadList[i] = gin.H{
+			"queue_id": q.ID,
+			"name":     q.Name,
+			"order":    q.Order,
+		}
+	}
+
+	runningIDs := make([]string, len(running))
+	for i, q := range running {
+		runningIDs[i] = q.ID
+	}
+
+	summary := "队列将在客户端下次同步时开始执行"
+	if len(blockers) > 0 {
+		summary = blockers[0]["message"].(string)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"queue_id":          queue.ID,
+		"status":            queue.Status,
+		"waiting":           true,
+		"position":          len(ahead) + 1,
+		"order":             queue.Order,
+		"ahead":             aheadList,
+		"running":           runningIDs,
+		"connection_status": unit.ConnectionStatus,
+		"last_heartbeat":    unit.LastHeartbeat,
+		"blockers":          blockers,
+		"summary":           summary,
+	})
+}
+
+// ReorderQueuesRequest 队列重新排序请求体
+type ReorderQueuesRequest struct {
+	QueueIDs []string `json:"queue_ids" binding:"required"`
+}
+
 // ReorderQueues 重新排序队列
 // 只能调整pending队列，不能调整到running/completed之前
 func (h *QueueHandlerV2) ReorderQueues(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		QueueIDs []string `json:"queue_ids" binding:"required"`
-	}
+	var req ReorderQueuesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -516,6 +1022,11 @@ func (h *QueueHandlerV2) ReorderQueues(c *gin.Context) {
 		return
 	}
 
+	// 调整前保存当前版本快照，便于回溯重新排序前的计划
+	if _, err := services.SnapshotUnit(&unit, "pre_reorder"); err != nil {
+		log.Printf("Failed to snapshot unit %s before reorder: %v", unit.ID, err)
+	}
+
 	// 获取所有待调整的队列
 	var queuesToReorder []models.TrainingQueue
 	if err := database.DB.Where("id IN ? AND user_id = ?", req.QueueIDs, userID).
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReceiptHandler struct {
+	receiptService *services.ReceiptService
+}
+
+func NewReceiptHandler() *ReceiptHandler {
+	return &ReceiptHandler{receiptService: services.NewReceiptService()}
+}
+
+// GetQueueReceipt 获取队列最新的执行回执（download=true时作为文件下载）
+func (h *ReceiptHandler) GetQueueReceipt(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var receipt models.ExecutionReceipt
+	if err := database.DB.Where("queue_id = ? AND user_id = ?", queueID, userID).
+		Order("issued_at DESC").
+		First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "执行回执不存在",
+		})
+		return
+	}
+
+	if c.Query("download") == "true" {
+		data, err := json.MarshalIndent(receipt, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "导出执行回执失败",
+			})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", receipt.ID))
+		c.Data(http.StatusOK, "application/json", data)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"receipt": receipt,
+	})
+}
+
+// VerifyQueueReceipt 校验回执签名及队列当前数据是否与回执一致
+func (h *ReceiptHandler) VerifyQueueReceipt(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var receipt models.ExecutionReceipt
+	if err := database.DB.Where("queue_id = ? AND user_id = ?", queueID, userID).
+		Order("issued_at DESC").
+		First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "执行回执不存在",
+		})
+		return
+	}
+
+	signatureValid := h.receiptService.VerifySignature(&receipt)
+
+	// 队列可能已被删除，此时只校验签名
+	var contentMatches map[string]bool
+	var queue models.TrainingQueue
+	if err := database.DB.Where("id = ? AND user_id = ?", queueID, userID).
+		First(&queue).Error; err == nil {
+		contentMatches = h.receiptService.VerifyQueue(&receipt, &queue)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"receipt_id":      receipt.ID,
+		"signature_valid": signatureValid,
+		"content_matches": contentMatches,
+	})
+}
+
+// GetPublicKey 公布回执签名公钥及历史公钥（无需认证，供第三方离线校验下载的回执）
+func (h *ReceiptHandler) GetPublicKey(c *gin.Context) {
+	publicKey := h.receiptService.PublicKey()
+	if publicKey == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "回执签名密钥不可用",
+		})
+		return
+	}
+
+	// 已轮换的公钥仍需公布，用于校验其签发的回执
+	keys, err := h.receiptService.PublicKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询回执公钥失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"algorithm":  services.ReceiptAlgorithm,
+		"key_id":     services.ReceiptKeyID(publicKey),
+		"public_key": base64.StdEncoding.EncodeToString(publicKey),
+		"keys":       keys,
+		"payload_fields": []string{
+			"algorithm", "key_id", "sequence", "receipt_id", "queue_id", "unit_id", "user_id",
+			"parameters_hash", "result_hash", "metrics_hash", "started_at", "completed_at", "issued_at", "prev_signature",
+		},
+	})
+}
+
+// VerifyReceipt 校验提交的回执内容（如下载的回执文件）的签名，无需认证
+func (h *ReceiptHandler) VerifyReceipt(c *gin.Context) {
+	var receipt models.ExecutionReceipt
+	if err := c.ShouldBindJSON(&receipt); err != nil || receipt.Signature == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的回执内容",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"receipt_id":      receipt.ID,
+		"algorithm":       receipt.Algorithm,
+		"key_id":          receipt.KeyID,
+		"signature_valid": h.receiptService.VerifySignature(&receipt),
+	})
+}
+
+// VerifyReceiptChain 校验当前用户全部回执的签名及签名链连续性
+func (h *ReceiptHandler) VerifyReceiptChain(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	count, breaks, err := h.receiptService.VerifyChain(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "校验签名链失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"receipts":    count,
+		"chain_valid": len(breaks) == 0,
+		"breaks":      breaks,
+	})
+}
+
+// ListUnitReceipts 列出训练单元下的所有执行回执
+func (h *ReceiptHandler) ListUnitReceipts(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var receipts []models.ExecutionReceipt
+	if err := database.DB.Where("unit_id = ? AND user_id = ?", unitID, userID).
+		Order("issued_at DESC").
+		Find(&receipts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询执行回执失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"receipts": receipts,
+		"count":    len(receipts),
+	})
+}
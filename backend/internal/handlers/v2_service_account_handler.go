@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ServiceAccountHandler struct{}
+
+func NewServiceAccountHandler() *ServiceAccountHandler {
+	return &ServiceAccountHandler{}
+}
+
+// CreateServiceAccountRequest 创建服务账号请求体
+type CreateServiceAccountRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateServiceAccount 创建服务账号（供CI、调度器等自动化使用）
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req CreateServiceAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	// 仅在未指定时默认read+write
+	scopes, ok := models.ScopeRead+","+models.ScopeWrite, true
+	if req.Scopes != nil {
+		scopes, ok = normalizeScopes(req.Scopes)
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的权限范围，需为read/write中的至少一个",
+		})
+		return
+	}
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "生成API Key失败",
+		})
+		return
+	}
+
+	account := models.User{
+		ID:      "sa_" + uuid.New().String()[:8],
+		APIKey:  apiKey,
+		Type:    models.UserTypeServiceAccount,
+		Name:    req.Name,
+		OwnerID: userID,
+		Scopes:  scopes,
+	}
+
+	if err := database.DB.Create(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建服务账号失败",
+		})
+		return
+	}
+
+	// API Key仅在创建和轮换时返回
+	c.JSON(http.StatusCreated, gin.H{
+		"success":         true,
+		"service_account": serviceAccountView(&account),
+		"api_key":         account.APIKey,
+	})
+}
+
+// ListServiceAccounts 列出当前用户的服务账号
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var accounts []models.User
+	if err := database.DB.Where("owner_id = ? AND type = ?", userID, models.UserTypeServiceAccount).
+		Order("created_at DESC").
+		Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询服务账号失败",
+		})
+		return
+	}
+
+	views := make([]gin.H, len(accounts))
+	for i := range accounts {
+		views[i] = serviceAccountView(&accounts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"service_accounts": views,
+	})
+}
+
+// UpdateServiceAccountRequest 更新服务账号请求体（scopes为空列表时返回400）
+type UpdateServiceAccountRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// UpdateServiceAccount 更新服务账号名称或权限范围
+func (h *ServiceAccountHandler) UpdateServiceAccount(c *gin.Context) {
+	accountID := c.Param("account_id")
+	userID := middleware.GetUserID(c)
+
+	var req UpdateServiceAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数",
+		})
+		return
+	}
+
+	var account models.User
+	if err := database.DB.Where("id = ? AND owner_id = ? AND type = ?", accountID, userID, models.UserTypeServiceAccount).
+		First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "服务账号不存在",
+		})
+		return
+	}
+
+	if req.Name != "" {
+		account.Name = req.Name
+	}
+	if req.Scopes != nil {
+		scopes, ok := normalizeScopes(req.Scopes)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的权限范围，需为read/write中的至少一个",
+			})
+			return
+		}
+		account.Scopes = scopes
+	}
+
+	if err := database.DB.Save(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "更新服务账号失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"service_account": serviceAccountView(&account),
+	})
+}
+
+// RotateServiceAccountKey 轮换服务账号的API Key（旧Key立即失效）
+func (h *ServiceAccountHandler) RotateServiceAccountKey(c *gin.Context) {
+	accountID := c.Param("account_id")
+	userID := middleware.GetUserID(c)
+
+	var account models.User
+	if err := database.DB.Where("id = ? AND owner_id = ? AND type = ?", accountID, userID, models.UserTypeServiceAccount).
+		First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "服务账号不存在",
+		})
+		return
+	}
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "生成API Key失败",
+		})
+		return
+	}
+
+	if err := database.DB.Model(&account).Update("api_key", apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "轮换API Key失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"service_account": serviceAccountView(&account),
+		"api_key":         apiKey,
+	})
+}
+
+// DeleteServiceAccount 删除服务账号（其创建的资源仍归属于所属用户）
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	accountID := c.Param("account_id")
+	userID := middleware.GetUserID(c)
+
+	result := database.DB.Where("id = ? AND owner_id = ? AND type = ?", accountID, userID, models.UserTypeServiceAccount).
+		Delete(&models.User{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除服务账号失败",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "服务账号不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "服务账号已删除",
+	})
+}
+
+// normalizeScopes 校验并合并权限范围，空列表无效（服务账号至少需要一个权限）
+func normalizeScopes(scopes []string) (string, bool) {
+	if len(scopes) == 0 {
+		return "", false
+	}
+
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope != models.ScopeRead && scope != models.ScopeWrite {
+			return "", false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return strings.Join(result, ","), true
+}
+
+// serviceAccountView 服务账号的对外展示（不包含API Key）
+func serviceAccountView(account *models.User) gin.H {
+	return gin.H{
+		"account_id": account.ID,
+		"name":       account.Name,
+		"owner_id":   account.OwnerID,
+		"scopes":     account.ScopeList(),
+		"created_at": account.CreatedAt,
+	}
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "sk_sa_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnapshotHandler struct{}
+
+func NewSnapshotHandler() *SnapshotHandler {
+	return &SnapshotHandler{}
+}
+
+// CreateSnapshot 手动保存训练单元当前版本的快照
+func (h *SnapshotHandler) CreateSnapshot(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	snapshot, err := services.SnapshotUnit(&unit, "manual")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "保存快照失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"snapshot": snapshot,
+	})
+}
+
+// GetSnapshot 获取训练单元在指定版本时的快照
+// 指定版本没有快照时返回该版本之前最近的快照，未指定版本时返回最新快照
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	query := database.DB.Where("unit_id = ?", unitID)
+
+	requested := 0
+	if versionStr := c.Query("version"); versionStr != "" {
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "无效的版本号",
+			})
+			return
+		}
+		requested = version
+		query = query.Where("version <= ?", version)
+	}
+
+	var snapshot models.UnitSnapshot
+	if err := query.Order("version DESC").
+		First(&snapshot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "该版本之前没有快照",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"requested_version": requested,
+		"exact":             requested == 0 || snapshot.Version == requested,
+		"current_version":   unit.Version,
+		"snapshot":          snapshot,
+	})
+}
+
+// ListSnapshots 列出训练单元的快照（不含快照内容）
+func (h *SnapshotHandler) ListSnapshots(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var snapshots []models.UnitSnapshot
+	if err := database.DB.Select("id", "unit_id", "version", "trigger", "created_at").
+		Where("unit_id = ? AND user_id = ?", unitID, userID).
+		Order("version DESC").
+		Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询快照失败",
+		})
+		return
+	}
+
+	list := make([]gin.H, len(snapshots))
+	for i, s := range snapshots {
+		list[i] = gin.H{
+			"snapshot_id": s.ID,
+			"version":     s.Version,
+			"trigger":     s.Trigger,
+			"created_at":  s.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"snapshots": list,
+	})
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrashHandler struct{}
+
+func NewTrashHandler() *TrashHandler {
+	return &TrashHandler{}
+}
+
+// TrashedUnit 回收站中的训练单元（附带队列数和永久删除时间）
+type TrashedUnit struct {
+	models.TrainingUnit
+	QueueCount int64     `json:"queue_count"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// TrashedQueue 回收站中的训练队列（附带永久删除时间）
+type TrashedQueue struct {
+	models.TrainingQueue
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ListTrash 列出回收站中的训练单元和队列
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	retention := services.TrashRetention()
+
+	var units []models.TrainingUnit
+	if err := database.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&units).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询回收站失败",
+		})
+		return
+	}
+
+	// 随训练单元一起删除的队列不单独列出，恢复单元时一并恢复
+	trashedUnitIDs := database.DB.Unscoped().
+		Model(&models.TrainingUnit{}).
+		Select("id").
+		Where("deleted_at IS NOT NULL")
+
+	var queues []models.TrainingQueue
+	if err := database.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Where("unit_id NOT IN (?)", trashedUnitIDs).
+		Order("deleted_at DESC").
+		Find(&queues).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "查询回收站失败",
+		})
+		return
+	}
+
+	trashedUnits := make([]TrashedUnit, len(units))
+	for i, unit := range units {
+		var count int64
+		database.DB.Unscoped().
+			Model(&models.TrainingQueue{}).
+			Where("unit_id = ? AND deleted_at = ?", unit.ID, unit.DeletedAt.Time).
+			Count(&count)
+
+		trashedUnits[i] = TrashedUnit{
+			TrainingUnit: unit,
+			QueueCount:   count,
+			ExpiresAt:    unit.DeletedAt.Time.Add(retention),
+		}
+	}
+
+	trashedQueues := make([]TrashedQueue, len(queues))
+	for i, queue := range queues {
+		trashedQueues[i] = TrashedQueue{
+			TrainingQueue: queue,
+			ExpiresAt:     queue.DeletedAt.Time.Add(retention),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"units":          trashedUnits,
+		"queues":         trashedQueues,
+		"retention_days": int(retention.Hours() / 24),
+	})
+}
+
+// RestoreUnit 从回收站恢复训练单元及随其删除的队列
+func (h *TrashHandler) RestoreUnit(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "回收站中不存在该训练单元",
+		})
+		return
+	}
+
+	if time.Since(unit.DeletedAt.Time) > services.TrashRetention() {
+		c.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   "训练单元已超过回收站保留期",
+		})
+		return
+	}
+
+	deletedAt := unit.DeletedAt.Time
+	var restoredCount int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var queues []models.TrainingQueue
+		if err := tx.Unscoped().
+			Where("unit_id = ? AND deleted_at = ?", unit.ID, deletedAt).
+			Order("\"order\" ASC").
+			Find(&queues).Error; err != nil {
+			return err
+		}
+		if err := restoreQueues(tx, unit.ID, queues); err != nil {
+			return err
+		}
+		restoredCount = int64(len(queues))
+
+		// 版本号递增，通知Python客户端重新同步
+		return tx.Unscoped().Model(&unit).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    unit.Version + 1,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "恢复训练单元失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"unit_id":         unit.ID,
+		"restored_queues": restoredCount,
+		"message":         "训练单元已恢复",
+	})
+}
+
+// RestoreQueue 从回收站恢复单个队列
+func (h *TrashHandler) RestoreQueue(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var queue models.TrainingQueue
+	if err := database.DB.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "回收站中不存在该队列",
+		})
+		return
+	}
+
+	if time.Since(queue.DeletedAt.Time) > services.TrashRetention() {
+		c.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   "队列已超过回收站保留期",
+		})
+		return
+	}
+
+	// 所属训练单元也在回收站中时，需先恢复训练单元
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ?", queue.UnitID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "所属训练单元已被删除，请先恢复训练单元",
+		})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return restoreQueues(tx, unit.ID, []models.TrainingQueue{queue})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "恢复队列失败",
+		})
+		return
+	}
+
+	// 更新训练单元版本号
+	database.DB.Model(&unit).Update("version", unit.Version+1)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"queue_id": queue.ID,
+		"message":  "队列已恢复",
+	})
+}
+
+// PurgeUnit 从回收站永久删除训练单元及其队列
+func (h *TrashHandler) PurgeUnit(c *gin.Context) {
+	unitID := c.Param("unit_id")
+	userID := middleware.GetUserID(c)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "回收站中不存在该训练单元",
+		})
+		return
+	}
+
+	_, queues, err := services.PurgeUnits([]string{unit.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "永久删除训练单元失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"unit_id":       unit.ID,
+		"purged_queues": queues,
+		"message":       "训练单元已永久删除",
+	})
+}
+
+// PurgeQueue 从回收站永久删除单个队列
+func (h *TrashHandler) PurgeQueue(c *gin.Context) {
+	queueID := c.Param("queue_id")
+	userID := middleware.GetUserID(c)
+
+	var queue models.TrainingQueue
+	if err := database.DB.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", queueID, userID).
+		First(&queue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "回收站中不存在该队列",
+		})
+		return
+	}
+
+	if _, err := services.PurgeQueues([]string{queue.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "永久删除队列失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"queue_id": queue.ID,
+		"message":  "队列已永久删除",
+	})
+}
+
+// restoreQueues 恢复队列并追加到训练单元队尾（原顺序可能已被新建队列占用）
+func restoreQueues(tx *gorm.DB, unitID string, queues []models.TrainingQueue) error {
+	var maxOrder int
+	if err := tx.Model(&models.TrainingQueue{}).
+		Where("unit_id = ?", unitID).
+		Select("COALESCE(MAX(\"order\"), -1)").
+		Scan(&maxOrder).Error; err != nil {
+		return err
+	}
+
+	for i, queue := range queues {
+		if err := tx.Unscoped().Model(&queue).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"order":      maxOrder + 1 + i,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,9 +7,11 @@ import (
 	"MLQueue/internal/database"
 	"MLQueue/internal/middleware"
 	"MLQueue/internal/models"
+	"MLQueue/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type UnitHandler struct{}
@@ -18,16 +20,25 @@ func NewUnitHandler() *UnitHandler {
 	return &UnitHandler{}
 }
 
+// UnitWithCount 训练单元列表项（附带队列数）
+type UnitWithCount struct {
+	models.TrainingUnit
+	QueueCount int64 `json:"queue_count"`
+}
+
+// CreateUnitRequest 创建训练单元请求体
+type CreateUnitRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Config      map[string]interface{} `json:"config"`
+}
+
 // CreateTrainingUnit 创建训练单元（Python客户端调用）
 func (h *UnitHandler) CreateTrainingUnit(c *gin.Context) {
 	groupID := c.Param("group_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name        string                 `json:"name" binding:"required"`
-		Description string                 `json:"description"`
-		Config      map[string]interface{} `json:"config"`
-	}
+	var req CreateUnitRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -102,11 +113,6 @@ func (h *UnitHandler) ListTrainingUnits(c *gin.Context) {
 	}
 
 	// 为每个单元统计队列数
-	type UnitWithCount struct {
-		models.TrainingUnit
-		QueueCount int64 `json:"queue_count"`
-	}
-
 	unitsWithCount := make([]UnitWithCount, len(units))
 	for i, unit := range units {
 		// 检查并更新连接状态
@@ -153,14 +159,17 @@ func (h *UnitHandler) GetTrainingUnit(c *gin.Context) {
 	})
 }
 
+// SyncUnitRequest 同步训练单元请求体
+type SyncUnitRequest struct {
+	ClientVersion int `json:"client_version"` // Python客户端当前版本
+}
+
 // SyncTrainingUnit Python客户端同步训练单元（拉取云端最新配置）
 func (h *UnitHandler) SyncTrainingUnit(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		ClientVersion int `json:"client_version"` // Python客户端当前版本
-	}
+	var req SyncUnitRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -198,16 +207,19 @@ func (h *UnitHandler) SyncTrainingUnit(c *gin.Context) {
 	})
 }
 
+// UpdateUnitRequest 更新训练单元请求体
+type UpdateUnitRequest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Config      map[string]interface{} `json:"config"`
+}
+
 // UpdateTrainingUnit 更新训练单元（前端或Python客户端）
 func (h *UnitHandler) UpdateTrainingUnit(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	var req struct {
-		Name        string                 `json:"name"`
-		Description string                 `json:"description"`
-		Config      map[string]interface{} `json:"config"`
-	}
+	var req UpdateUnitRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -254,27 +266,46 @@ func (h *UnitHandler) UpdateTrainingUnit(c *gin.Context) {
 	})
 }
 
-// DeleteTrainingUnit 删除训练单元
+// DeleteTrainingUnit 删除训练单元（连同其队列移入回收站，保留期内可恢复）
 func (h *UnitHandler) DeleteTrainingUnit(c *gin.Context) {
 	unitID := c.Param("unit_id")
 	userID := middleware.GetUserID(c)
 
-	// 检查是否有训练队列
-	var count int64
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
+		First(&unit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "训练单元不存在",
+		})
+		return
+	}
+
+	// 检查是否有运行中的训练队列
+	var runningCount int64
 	database.DB.Model(&models.TrainingQueue{}).
-		Where("unit_id = ?", unitID).
-		Count(&count)
+		Where("unit_id = ? AND status = ?", unitID, "running").
+		Count(&runningCount)
 
-	if count > 0 {
+	if runningCount > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "训练单元内还有训练队列，无法删除",
+			"error":   "训练单元内有运行中的队列，无法删除",
 		})
 		return
 	}
 
-	if err := database.DB.Where("id = ? AND user_id = ?", unitID, userID).
-		Delete(&models.TrainingUnit{}).Error; err != nil {
+	// 单元与队列使用相同的删除时间，恢复单元时据此一并恢复队列
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TrainingQueue{}).
+			Where("unit_id = ?", unitID).
+			Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&unit).Update("deleted_at", now).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "删除训练单元失败",
@@ -283,8 +314,9 @@ func (h *UnitHandler) DeleteTrainingUnit(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "训练单元已删除",
+		"success":    true,
+		"message":    "训练单元已移入回收站",
+		"expires_at": now.Add(services.TrashRetention()),
 	})
 }
 
@@ -316,10 +348,20 @@ func (h *UnitHandler) Heartbeat(c *gin.Context) {
 		return
 	}
 
+	// 附带运行中队列的待处理控制命令（如提前停止）
+	var commands []models.QueueCommand
+	database.DB.Where("unit_id = ? AND status = ?", unit.ID, "pending").
+		Where("queue_id IN (?)", database.DB.Model(&models.TrainingQueue{}).
+			Select("id").
+			Where("unit_id = ? AND status = ?", unit.ID, "running")).
+		Order("created_at ASC").
+		Find(&commands)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":           true,
 		"connection_status": unit.ConnectionStatus,
 		"last_heartbeat":    unit.LastHeartbeat,
+		"commands":          commands,
 	})
 }
 
@@ -50,9 +50,42 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Service accounts act on behalf of their owner: resources they create
+		// are attributed to the owner, and their key is limited to its scopes
+		ownerID := user.ID
+		tier := user.Tier
+		if user.IsServiceAccount() {
+			var owner models.User
+			if err := database.DB.Where("id = ?", user.OwnerID).First(&owner).Error; err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "服务账号所属用户不存在",
+					"code":    "INVALID_TOKEN",
+				})
+				c.Abort()
+				return
+			}
+
+			if !user.HasScope(models.ScopeForMethod(c.Request.Method)) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"error":   "服务账号权限不足",
+					"code":    "INSUFFICIENT_SCOPE",
+				})
+				c.Abort()
+				return
+			}
+
+			ownerID = owner.ID
+			tier = owner.Tier
+		}
+
 		// Store user info in context
-		c.Set("user_id", user.ID)
-		c.Set("user_tier", user.Tier)
+		c.Set("user_id", ownerID)
+		c.Set("actor_id", user.ID)
+		c.Set("user_type", user.Type)
+		c.Set("user_tier", tier)
+		c.Set("is_admin", user.IsAdmin())
 		c.Next()
 	}
 }
@@ -65,6 +98,63 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// GetActorID retrieves the ID of the account that made the request
+// (the service account itself, rather than its owner)
+func GetActorID(c *gin.Context) string {
+	if actorID, exists := c.Get("actor_id"); exists {
+		return actorID.(string)
+	}
+	return GetUserID(c)
+}
+
+// IsServiceAccount reports whether the request was made by a service account
+func IsServiceAccount(c *gin.Context) bool {
+	if userType, exists := c.Get("user_type"); exists {
+		return userType.(string) == models.UserTypeServiceAccount
+	}
+	return false
+}
+
+// IsAdmin reports whether the request was made by an organization admin
+func IsAdmin(c *gin.Context) bool {
+	if isAdmin, exists := c.Get("is_admin"); exists {
+		return isAdmin.(bool)
+	}
+	return false
+}
+
+// AdminMiddleware restricts a route to organization admins
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "需要组织管理员权限",
+				"code":    "ADMIN_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HumanOnlyMiddleware rejects service account keys (e.g. service accounts cannot manage service accounts)
+func HumanOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsServiceAccount(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "服务账号无法执行此操作",
+				"code":    "INSUFFICIENT_SCOPE",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetUserTier retrieves user tier from context
 func GetUserTier(c *gin.Context) string {
 	if tier, exists := c.Get("user_tier"); exists {
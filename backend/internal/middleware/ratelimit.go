@@ -16,6 +16,7 @@ import (
 // RateLimitMiddleware implements token bucket rate limiting
 func RateLimitMiddleware(isBatch bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Service accounts share their owner's bucket, so extra accounts do not add quota
 		userID := GetUserID(c)
 		tier := GetUserTier(c)
 
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"net/http"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -63,15 +65,78 @@ type Test struct {
 	DD string `json:"dd"`
 }
 
+const (
+	UserTypeHuman          = "user"
+	UserTypeServiceAccount = "service_account"
+)
+
+// Organization roles; admins can view cross-user reports
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
+
+// Scopes granted to service account keys
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
 type User struct {
 	ID        string    `json:"user_id" gorm:"primaryKey;type:varchar(100)"`
-	Email     string    `json:"email" gorm:"uniqueIndex;type:varchar(255)"`
+	Email     *string   `json:"email" gorm:"uniqueIndex;type:varchar(255)"` // nil for service accounts
 	APIKey    string    `json:"api_key" gorm:"uniqueIndex;type:varchar(100)"`
 	Tier      string    `json:"tier" gorm:"type:varchar(20);default:'standard'"` // standard, premium
+	Type      string    `json:"type" gorm:"type:varchar(20);default:'user'"`     // user, service_account
+	Role      string    `json:"role" gorm:"type:varchar(20);default:'member'"`   // member, admin
+	Name      string    `json:"name" gorm:"type:varchar(255)"`
+	OwnerID   string    `json:"owner_id,omitempty" gorm:"type:varchar(100);index"` // Parent account of a service account
+	Scopes    string    `json:"scopes,omitempty" gorm:"type:varchar(255)"`         // Comma separated, service accounts only
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"-"`
 }
 
+// IsServiceAccount reports whether the user is an automation account
+func (u *User) IsServiceAccount() bool {
+	return u.Type == UserTypeServiceAccount
+}
+
+// ScopeForMethod maps a request method to the scope a service account key needs
+func ScopeForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// IsAdmin reports whether the user is an organization admin
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin && !u.IsServiceAccount()
+}
+
+// ScopeList returns the scopes granted to a service account
+func (u *User) ScopeList() []string {
+	scopes := make([]string, 0)
+	for _, scope := range strings.Split(u.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// HasScope reports whether a service account key grants the scope
+func (u *User) HasScope(scope string) bool {
+	for _, s := range u.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 type WebhookConfig struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
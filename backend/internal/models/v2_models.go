@@ -1,7 +1,10 @@
 package models
 
 import (
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Group 代表一个ML项目组
@@ -41,6 +44,9 @@ type TrainingUnit struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// 回收站：非空表示已移入回收站，可在保留期内恢复
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
 	// 关联
 	UserID string `json:"user_id" gorm:"type:varchar(100);index"`
 
@@ -77,20 +83,227 @@ type TrainingQueue struct {
 	Metrics  JSONB  `json:"metrics" gorm:"type:jsonb"` // 训练指标
 	ErrorMsg string `json:"error_msg" gorm:"type:text"`
 
+	// 指标异常标记（由异常检测规则设置）
+	AnomalyDetected bool `json:"anomaly_detected" gorm:"default:false"`
+
 	// 元数据
-	CreatedBy string    `json:"created_by" gorm:"type:varchar(20)"` // 'client' or 'web'
+	CreatedBy string    `json:"created_by" gorm:"type:varchar(20)"`      // 'client' or 'web'
+	ActorID   string    `json:"actor_id" gorm:"type:varchar(100);index"` // 创建队列的账号（服务账号创建时为服务账号ID）
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// 回收站：非空表示已移入回收站，可在保留期内恢复
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
 	// 关联
 	UserID string `json:"user_id" gorm:"type:varchar(100);index"`
 }
 
+// ErrReceiptImmutable 执行回执只允许追加，不允许修改或删除
+var ErrReceiptImmutable = errors.New("execution receipt is append-only")
+
+// ExecutionReceipt 训练队列完成后生成的签名回执（只追加，不可修改）
+type ExecutionReceipt struct {
+	ID      string `json:"receipt_id" gorm:"primaryKey;type:varchar(100)"`
+	QueueID string `json:"queue_id" gorm:"type:varchar(100);index"`
+	UnitID  string `json:"unit_id" gorm:"type:varchar(100);index"`
+
+	// 内容哈希（SHA-256，基于JSON规范化序列化）
+	ParametersHash string `json:"parameters_hash" gorm:"type:varchar(64)"`
+	ResultHash     string `json:"result_hash" gorm:"type:varchar(64)"`
+	MetricsHash    string `json:"metrics_hash" gorm:"type:varchar(64)"`
+
+	// 执行时间
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt time.Time  `json:"completed_at"`
+	IssuedAt    time.Time  `json:"issued_at"`
+
+	// 签名链：Sequence 为同一用户内的签发序号，PrevSignature 为上一张回执的签名
+	Sequence      int64  `json:"sequence" gorm:"index"`
+	PrevSignature string `json:"prev_signature" gorm:"type:varchar(128)"`
+	Signature     string `json:"signature" gorm:"type:varchar(128);uniqueIndex"`
+	Algorithm     string `json:"algorithm" gorm:"type:varchar(20)"`
+	KeyID         string `json:"key_id" gorm:"type:varchar(32)"` // 签名公钥指纹
+
+	// 关联
+	UserID string `json:"user_id" gorm:"type:varchar(100);index"`
+}
+
+// BeforeUpdate 禁止修改已生成的回执
+func (r *ExecutionReceipt) BeforeUpdate(tx *gorm.DB) error {
+	return ErrReceiptImmutable
+}
+
+// BeforeDelete 禁止删除已生成的回执
+func (r *ExecutionReceipt) BeforeDelete(tx *gorm.DB) error {
+	return ErrReceiptImmutable
+}
+
+// ReceiptSigningKey 签发过回执的公钥（轮换后保留，按KeyID校验历史回执）
+type ReceiptSigningKey struct {
+	KeyID     string    `json:"key_id" gorm:"primaryKey;type:varchar(32)"`
+	PublicKey string    `json:"public_key" gorm:"type:varchar(64);not null"` // base64
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MetricReport 训练过程中Python客户端上报的指标（流式）
+type MetricReport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	QueueID   string    `json:"queue_id" gorm:"type:varchar(100);index"`
+	Step      int       `json:"step"`
+	Metrics   JSONB     `json:"metrics" gorm:"type:jsonb"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// 异常检测规则类型
+const (
+	AnomalyRuleNaN           = "nan"            // 指标为NaN或Inf
+	AnomalyRuleNoImprovement = "no_improvement" // 连续N次上报没有改进
+	AnomalyRuleBounds        = "bounds"         // 指标超出上下界
+)
+
+// AnomalyRule 训练单元级别的指标异常检测规则
+type AnomalyRule struct {
+	ID     string `json:"rule_id" gorm:"primaryKey;type:varchar(100)"`
+	UnitID string `json:"unit_id" gorm:"type:varchar(100);index"`
+	Type   string `json:"type" gorm:"type:varchar(20);not null"`
+
+	// 检测的指标名（nan规则为空时检测所有指标）
+	Metric string `json:"metric" gorm:"type:varchar(100)"`
+
+	// no_improvement: Patience为允许不改进的上报次数，Mode为min/max
+	Patience int    `json:"patience"`
+	Mode     string `json:"mode" gorm:"type:varchar(10)"`
+
+	// bounds: 上下界（可只设置其一）
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+
+	// 触发后的动作（始终标记运行）
+	Notify    bool `json:"notify"`
+	EarlyStop bool `json:"early_stop"`
+
+	Enabled   bool      `json:"enabled"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnomalyEvent 规则触发记录（每个规则在每个队列上只记录一次）
+type AnomalyEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	QueueID   string    `json:"queue_id" gorm:"type:varchar(100);index"`
+	UnitID    string    `json:"unit_id" gorm:"type:varchar(100);index"`
+	RuleID    string    `json:"rule_id" gorm:"type:varchar(100);index"`
+	RuleType  string    `json:"rule_type" gorm:"type:varchar(20)"`
+	Metric    string    `json:"metric" gorm:"type:varchar(100)"`
+	Value     string    `json:"value" gorm:"type:varchar(50)"` // 字符串存储，NaN无法JSON序列化
+	Message   string    `json:"message" gorm:"type:text"`
+	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnomalyRuleState 规则在单个队列上的运行状态（增量检测，避免每次上报重新读取历史指标）
+type AnomalyRuleState struct {
+	RuleID  string `json:"rule_id" gorm:"primaryKey;type:varchar(100)"`
+	QueueID string `json:"queue_id" gorm:"primaryKey;type:varchar(100);index"`
+
+	// no_improvement: 当前最佳值及其后未改进的上报次数
+	Best  *float64 `json:"best"`
+	Stale int      `json:"stale"`
+
+	// 规则已在该队列上触发（每个规则在每个队列上只触发一次）
+	Triggered bool `json:"triggered"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QueueCommand 下发给Python客户端的控制命令
+type QueueCommand struct {
+	ID      string `json:"command_id" gorm:"primaryKey;type:varchar(100)"`
+	QueueID string `json:"queue_id" gorm:"type:varchar(100);index"`
+	UnitID  string `json:"unit_id" gorm:"type:varchar(100);index"`
+	Command string `json:"command" gorm:"type:varchar(20);not null"` // stop
+	Reason  string `json:"reason" gorm:"type:text"`
+
+	// 下发者：用户/服务账号ID，异常检测规则自动下发时为"rule:<rule_id>"
+	IssuedBy string `json:"issued_by" gorm:"type:varchar(100)"`
+
+	// pending: 等待客户端处理, acknowledged: 客户端已确认, expired: 队列已结束运行
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+
+	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NotificationRoute 通知路由：UnitID为空时是组级默认配置，否则是单元级覆盖
+type NotificationRoute struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	GroupID string `json:"group_id" gorm:"type:varchar(100);index"`
+	UnitID  string `json:"unit_id" gorm:"type:varchar(100);index"`
+
+	// 通知渠道（单元级配置中非空的渠道覆盖组级配置）
+	WebhookURL      string `json:"webhook_url" gorm:"type:varchar(500)"`
+	SlackWebhookURL string `json:"slack_webhook_url" gorm:"type:varchar(500)"`
+	SlackChannel    string `json:"slack_channel" gorm:"type:varchar(100)"`
+	EmailTo         string `json:"email_to" gorm:"type:text"` // 逗号分隔的收件人
+
+	// 订阅的事件，格式同WebhookConfig.Events，为空表示全部事件
+	Events JSONB `json:"events" gorm:"type:jsonb"`
+
+	// 单元级：静默该单元的所有通知
+	Muted bool `json:"muted"`
+
+	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PayloadBlob 超出内联大小限制的result/metrics，以文件形式存储，队列中只保留引用
+type PayloadBlob struct {
+	ID          string    `json:"payload_id" gorm:"primaryKey;type:varchar(100)"`
+	QueueID     string    `json:"queue_id" gorm:"type:varchar(100);index"`
+	Kind        string    `json:"kind" gorm:"type:varchar(20)"` // result/metrics
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256" gorm:"type:varchar(64)"`
+	StoragePath string    `json:"-" gorm:"type:varchar(500)"`
+	UserID      string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UnitSnapshot 训练单元在某个版本时的配置和完整队列列表
+type UnitSnapshot struct {
+	ID          uint   `json:"snapshot_id" gorm:"primaryKey"`
+	UnitID      string `json:"unit_id" gorm:"type:varchar(100);uniqueIndex:idx_unit_snapshot_version"`
+	Version     int    `json:"version" gorm:"uniqueIndex:idx_unit_snapshot_version"`
+	Name        string `json:"name" gorm:"type:varchar(255)"`
+	Description string `json:"description" gorm:"type:text"`
+	Config      JSONB  `json:"config" gorm:"type:jsonb"`
+	Queues      JSONB  `json:"queues" gorm:"type:jsonb"` // {"queues": [...]}，不含result/metrics
+
+	// 触发方式: periodic/manual/pre_reorder
+	Trigger string `json:"trigger" gorm:"type:varchar(20)"`
+
+	UserID    string    `json:"user_id" gorm:"type:varchar(100);index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // AutoMigrateV2 creates new tables
 func AutoMigrateV2(db interface{ AutoMigrate(...interface{}) error }) error {
 	return db.AutoMigrate(
 		&Group{},
 		&TrainingUnit{},
 		&TrainingQueue{},
+		&ExecutionReceipt{},
+		&MetricReport{},
+		&AnomalyRule{},
+		&AnomalyEvent{},
+		&AnomalyRuleState{},
+		&QueueCommand{},
+		&NotificationRoute{},
+		&PayloadBlob{},
+		&UnitSnapshot{},
+		&ReceiptSigningKey{},
 	)
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"MLQueue/internal/database"
@@ -27,6 +28,7 @@ type Manager struct {
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
 	paused      bool
+	busy        int32
 	mu          sync.RWMutex
 }
 
@@ -91,6 +93,9 @@ func (qm *Manager) worker(id int) {
 func (qm *Manager) processTask(workerID int, taskID string) {
 	log.Printf("Worker %d: processing task %s", workerID, taskID)
 
+	atomic.AddInt32(&qm.busy, 1)
+	defer atomic.AddInt32(&qm.busy, -1)
+
 	// Get task from database
 	var task models.Task
 	if err := database.DB.First(&task, "id = ?", taskID).Error; err != nil {
@@ -173,6 +178,38 @@ func (qm *Manager) GetQueuePosition(taskID string) (int64, error) {
 	return rank + 1, nil
 }
 
+// GetTasksAhead returns the task's 1-based queue position and the tasks ahead
+// of it in queue order with their priorities; position is -1 when the task is
+// not in the queue (e.g. it was dequeued concurrently)
+func (qm *Manager) GetTasksAhead(taskID string) (int64, []redis.Z, error) {
+	rank, err := qm.redis.ZRank(qm.ctx, TaskQueueKey, taskID).Result()
+	if err == redis.Nil {
+		return -1, []redis.Z{}, nil
+	}
+	if err != nil {
+		return -1, nil, err
+	}
+	if rank == 0 {
+		return 1, []redis.Z{}, nil
+	}
+
+	ahead, err := qm.redis.ZRangeWithScores(qm.ctx, TaskQueueKey, 0, rank-1).Result()
+	if err != nil {
+		return -1, nil, err
+	}
+	return rank + 1, ahead, nil
+}
+
+// WorkerCount returns the size of the worker pool
+func (qm *Manager) WorkerCount() int {
+	return qm.workerCount
+}
+
+// BusyWorkers returns the number of workers currently processing a task
+func (qm *Manager) BusyWorkers() int {
+	return int(atomic.LoadInt32(&qm.busy))
+}
+
 // UpdatePriority changes task priority in queue
 func (qm *Manager) UpdatePriority(taskID string, newPriority float64) error {
 	return qm.redis.ZAdd(qm.ctx, TaskQueueKey, redis.Z{
@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"time"
+
+	"MLQueue/internal/apimeta"
 	"MLQueue/internal/handlers"
 	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
 	"MLQueue/internal/queue"
 
 	"github.com/gin-gonic/gin"
@@ -19,52 +23,112 @@ func SetupRouter(qm *queue.Manager) *gin.Engine {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
-	// API v1 routes
-	v1 := router.Group("/v1")
+	// Protocol metadata for SDK generators (no authentication)
+	metaHandler := handlers.NewMetaHandler(router)
+	meta := apimeta.NewRouter(router.Group("/meta"))
+	{
+		meta.GET("/routes", apimeta.Spec{
+			Response: gin.H{"protocol_version": "", "routes": []apimeta.RouteInfo{}, "count": 0},
+		}, metaHandler.GetRoutes)
+		meta.GET("/changelog", apimeta.Spec{
+			Response: gin.H{"protocol_version": "", "changelog": []apimeta.ChangelogEntry{}},
+		}, metaHandler.GetChangelog)
+	}
+
+	// API v1 routes, registered with their request/response shapes for /meta/routes
+	v1 := apimeta.NewRouter(router.Group("/v1"))
 	{
 		// Authentication required for all routes
-		v1.Use(middleware.AuthMiddleware())
+		v1.Use(apimeta.Guard(middleware.AuthMiddleware(), apimeta.RequireAuth))
 
 		// Task routes
 		taskHandler := handlers.NewTaskHandler(qm)
 		tasks := v1.Group("/tasks")
 		{
-			tasks.POST("", middleware.RateLimitMiddleware(false), taskHandler.CreateTask)
-			tasks.POST("/batch", middleware.RateLimitMiddleware(true), taskHandler.BatchCreateTasks)
-			tasks.GET("", middleware.RateLimitMiddleware(false), taskHandler.ListTasks)
-			tasks.GET("/:task_id", middleware.RateLimitMiddleware(false), taskHandler.GetTask)
-			tasks.PATCH("/:task_id/priority", middleware.RateLimitMiddleware(false), taskHandler.UpdateTaskPriority)
-			tasks.POST("/:task_id/cancel", middleware.RateLimitMiddleware(false), taskHandler.CancelTask)
-			tasks.POST("/:task_id/result", middleware.RateLimitMiddleware(false), taskHandler.UploadResult)
+			tasks.POST("", apimeta.Spec{
+				Request:  handlers.CreateTaskRequest{},
+				Response: gin.H{"task_id": "", "status": "", "queue_position": int64(0)},
+			}, middleware.RateLimitMiddleware(false), taskHandler.CreateTask)
+			tasks.POST("/batch", apimeta.Spec{
+				Request:  handlers.BatchCreateTasksRequest{},
+				Response: gin.H{"task_ids": []string{}, "created_count": 0},
+			}, middleware.RateLimitMiddleware(true), taskHandler.BatchCreateTasks)
+			tasks.GET("", apimeta.Spec{
+				Response: gin.H{"tasks": []gin.H{}, "total": int64(0), "limit": 0, "offset": 0},
+			}, middleware.RateLimitMiddleware(false), taskHandler.ListTasks)
+			tasks.GET("/:task_id", apimeta.Spec{
+				Response: gin.H{
+					"task_id": "", "name": "", "config": models.JSONB{}, "priority": 0, "status": "",
+					"created_at": time.Time{}, "started_at": time.Time{}, "completed_at": time.Time{},
+					"result": models.JSONB{}, "error_message": "",
+				},
+			}, middleware.RateLimitMiddleware(false), taskHandler.GetTask)
+			tasks.PATCH("/:task_id/priority", apimeta.Spec{
+				Request:  handlers.UpdateTaskPriorityRequest{},
+				Response: gin.H{"task_id": "", "new_priority": 0, "new_queue_position": int64(0)},
+			}, middleware.RateLimitMiddleware(false), taskHandler.UpdateTaskPriority)
+			tasks.POST("/:task_id/cancel", apimeta.Spec{
+				Request:  handlers.CancelTaskRequest{},
+				Response: gin.H{"task_id": "", "status": ""},
+			}, middleware.RateLimitMiddleware(false), taskHandler.CancelTask)
+			tasks.POST("/:task_id/result", apimeta.Spec{
+				Request:  handlers.UploadResultRequest{},
+				Response: gin.H{"task_id": "", "status": ""},
+			}, middleware.RateLimitMiddleware(false), taskHandler.UploadResult)
+			tasks.GET("/:task_id/placement", apimeta.Spec{
+				Response: gin.H{
+					"task_id": "", "status": "", "waiting": false, "priority": 0, "position": int64(0),
+					"queue_length": int64(0), "paused": false, "workers": gin.H{}, "ahead": gin.H{},
+					"blockers": []gin.H{}, "summary": "",
+				},
+			}, middleware.RateLimitMiddleware(false), taskHandler.GetTaskPlacement)
 		}
 
 		// Queue routes
 		queueHandler := handlers.NewQueueHandler(qm)
 		queueGroup := v1.Group("/queue")
 		{
-			queueGroup.GET("/status", middleware.RateLimitMiddleware(false), queueHandler.GetQueueStatus)
-			queueGroup.POST("/reorder", middleware.RateLimitMiddleware(false), queueHandler.ReorderQueue)
-			queueGroup.POST("/pause", middleware.RateLimitMiddleware(false), queueHandler.PauseQueue)
-			queueGroup.POST("/resume", middleware.RateLimitMiddleware(false), queueHandler.ResumeQueue)
+			queueGroup.GET("/status", apimeta.Spec{
+				Response: gin.H{"queue_name": "", "statistics": gin.H{}, "current_tasks": []gin.H{}, "queue_length": int64(0), "estimated_wait_time": ""},
+			}, middleware.RateLimitMiddleware(false), queueHandler.GetQueueStatus)
+			queueGroup.POST("/reorder", apimeta.Spec{
+				Request:  handlers.ReorderQueueRequest{},
+				Response: gin.H{"message": "", "new_order": []gin.H{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.ReorderQueue)
+			queueGroup.POST("/pause", apimeta.Spec{
+				Response: gin.H{"queue_status": "", "message": ""},
+			}, middleware.RateLimitMiddleware(false), queueHandler.PauseQueue)
+			queueGroup.POST("/resume", apimeta.Spec{
+				Response: gin.H{"queue_status": "", "message": ""},
+			}, middleware.RateLimitMiddleware(false), queueHandler.ResumeQueue)
 		}
 
 		// Config routes
 		configHandler := handlers.NewConfigHandler()
 		configs := v1.Group("/configs")
 		{
-			configs.GET("/templates", middleware.RateLimitMiddleware(false), configHandler.GetTemplates)
-			configs.POST("/templates", middleware.RateLimitMiddleware(false), configHandler.CreateTemplate)
+			configs.GET("/templates", apimeta.Spec{
+				Response: gin.H{"templates": []gin.H{}},
+			}, middleware.RateLimitMiddleware(false), configHandler.GetTemplates)
+			configs.POST("/templates", apimeta.Spec{
+				Request:  handlers.CreateTemplateRequest{},
+				Response: gin.H{"template_id": "", "name": ""},
+			}, middleware.RateLimitMiddleware(false), configHandler.CreateTemplate)
 		}
 
 		// Statistics routes
 		statsHandler := handlers.NewStatisticsHandler()
 		statistics := v1.Group("/statistics")
 		{
-			statistics.GET("/tasks", middleware.RateLimitMiddleware(false), statsHandler.GetTaskStatistics)
+			statistics.GET("/tasks", apimeta.Spec{
+				Response: gin.H{"period": gin.H{}, "statistics": gin.H{}},
+			}, middleware.RateLimitMiddleware(false), statsHandler.GetTaskStatistics)
 		}
 
 		// Task logs
-		v1.GET("/tasks/:task_id/logs", middleware.RateLimitMiddleware(false), statsHandler.GetTaskLogs)
+		v1.GET("/tasks/:task_id/logs", apimeta.Spec{
+			Response: gin.H{"task_id": "", "logs": []gin.H{}},
+		}, middleware.RateLimitMiddleware(false), statsHandler.GetTaskLogs)
 	}
 
 	return router
@@ -1,72 +1,315 @@
 package routes
 
 import (
+	"time"
+
+	"MLQueue/internal/apimeta"
 	"MLQueue/internal/handlers"
 	"MLQueue/internal/middleware"
+	"MLQueue/internal/models"
+	"MLQueue/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupV2Routes 配置V2版本路由（Python客户端驱动架构）
+// 每个路由注册时附带请求/响应结构，认证要求由路由前的中间件推导，供 /meta/routes 使用
 func SetupV2Routes(router *gin.Engine) {
-	v2 := router.Group("/v2")
+	// 回执公钥与回执文件校验（无需认证，供审核方离线校验）
+	publicReceiptHandler := handlers.NewReceiptHandler()
+	publicReceipts := apimeta.NewRouter(router.Group("/v2/receipts"))
+	{
+		publicReceipts.GET("/public-key", apimeta.Spec{
+			Response: gin.H{"algorithm": "", "key_id": "", "public_key": "", "keys": []models.ReceiptSigningKey{}, "payload_fields": []string{}},
+		}, publicReceiptHandler.GetPublicKey)
+		publicReceipts.POST("/verify", apimeta.Spec{
+			Request:  models.ExecutionReceipt{},
+			Response: gin.H{"receipt_id": "", "algorithm": "", "key_id": "", "signature_valid": false},
+		}, publicReceiptHandler.VerifyReceipt)
+	}
+
+	v2 := apimeta.NewRouter(router.Group("/v2"))
 	{
 		// 需要认证
-		v2.Use(middleware.AuthMiddleware())
+		v2.Use(apimeta.Guard(middleware.AuthMiddleware(), apimeta.RequireAuth))
 
 		// ============ 组管理 ============
 		groupHandler := handlers.NewGroupHandler()
 		groups := v2.Group("/groups")
 		{
-			groups.POST("", middleware.RateLimitMiddleware(false), groupHandler.CreateGroup)
-			groups.GET("", middleware.RateLimitMiddleware(false), groupHandler.ListGroups)
-			groups.GET("/:group_id", middleware.RateLimitMiddleware(false), groupHandler.GetGroup)
-			groups.PUT("/:group_id", middleware.RateLimitMiddleware(false), groupHandler.UpdateGroup)
-			groups.DELETE("/:group_id", middleware.RateLimitMiddleware(false), groupHandler.DeleteGroup)
+			groups.POST("", apimeta.Spec{
+				Request:  handlers.CreateGroupRequest{},
+				Response: gin.H{"group_id": "", "name": ""},
+			}, middleware.RateLimitMiddleware(false), groupHandler.CreateGroup)
+			groups.GET("", apimeta.Spec{
+				Response: gin.H{"groups": []models.Group{}},
+			}, middleware.RateLimitMiddleware(false), groupHandler.ListGroups)
+			groups.GET("/:group_id", apimeta.Spec{
+				Response: gin.H{"group": models.Group{}, "unit_count": int64(0)},
+			}, middleware.RateLimitMiddleware(false), groupHandler.GetGroup)
+			groups.PUT("/:group_id", apimeta.Spec{
+				Request:  handlers.UpdateGroupRequest{},
+				Response: gin.H{"group": models.Group{}},
+			}, middleware.RateLimitMiddleware(false), groupHandler.UpdateGroup)
+			groups.DELETE("/:group_id", apimeta.Spec{
+				Response: gin.H{"message": ""},
+			}, middleware.RateLimitMiddleware(false), groupHandler.DeleteGroup)
 		}
 
 		// ============ 训练单元管理 ============
 		unitHandler := handlers.NewUnitHandler()
 
 		// 在组下创建训练单元
-		v2.POST("/groups/:group_id/units", middleware.RateLimitMiddleware(false), unitHandler.CreateTrainingUnit)
-		v2.GET("/groups/:group_id/units", middleware.RateLimitMiddleware(false), unitHandler.ListTrainingUnits)
+		v2.POST("/groups/:group_id/units", apimeta.Spec{
+			Request:  handlers.CreateUnitRequest{},
+			Response: gin.H{"unit_id": "", "version": 0},
+		}, middleware.RateLimitMiddleware(false), unitHandler.CreateTrainingUnit)
+		v2.GET("/groups/:group_id/units", apimeta.Spec{
+			Response: gin.H{"units": []handlers.UnitWithCount{}},
+		}, middleware.RateLimitMiddleware(false), unitHandler.ListTrainingUnits)
 
 		// 训练单元操作
 		units := v2.Group("/units")
 		{
-			units.GET("/:unit_id", middleware.RateLimitMiddleware(false), unitHandler.GetTrainingUnit)
-			units.PUT("/:unit_id", middleware.RateLimitMiddleware(false), unitHandler.UpdateTrainingUnit)
-			units.DELETE("/:unit_id", middleware.RateLimitMiddleware(false), unitHandler.DeleteTrainingUnit)
+			units.GET("/:unit_id", apimeta.Spec{
+				Response: gin.H{"unit": models.TrainingUnit{}},
+			}, middleware.RateLimitMiddleware(false), unitHandler.GetTrainingUnit)
+			units.PUT("/:unit_id", apimeta.Spec{
+				Request:  handlers.UpdateUnitRequest{},
+				Response: gin.H{"unit": models.TrainingUnit{}, "version": 0},
+			}, middleware.RateLimitMiddleware(false), unitHandler.UpdateTrainingUnit)
+			units.DELETE("/:unit_id", apimeta.Spec{
+				Response: gin.H{"message": "", "expires_at": time.Time{}},
+			}, middleware.RateLimitMiddleware(false), unitHandler.DeleteTrainingUnit)
 
 			// Python客户端同步端点
-			units.POST("/:unit_id/sync", middleware.RateLimitMiddleware(false), unitHandler.SyncTrainingUnit)
+			units.POST("/:unit_id/sync", apimeta.Spec{
+				Request:  handlers.SyncUnitRequest{},
+				Response: gin.H{"need_sync": false, "cloud_version": 0, "unit": models.TrainingUnit{}, "queues": []models.TrainingQueue{}},
+			}, middleware.RateLimitMiddleware(false), unitHandler.SyncTrainingUnit)
 			// Python客户端心跳端点
-			units.POST("/:unit_id/heartbeat", middleware.RateLimitMiddleware(false), unitHandler.Heartbeat)
+			units.POST("/:unit_id/heartbeat", apimeta.Spec{
+				Response: gin.H{"connection_status": "", "last_heartbeat": time.Time{}, "commands": []models.QueueCommand{}},
+			}, middleware.RateLimitMiddleware(false), unitHandler.Heartbeat)
 		}
 
+		// 训练单元快照（按版本回溯）
+		snapshotHandler := handlers.NewSnapshotHandler()
+		v2.POST("/units/:unit_id/snapshots", apimeta.Spec{
+			Response: gin.H{"snapshot": models.UnitSnapshot{}},
+		}, middleware.RateLimitMiddleware(false), snapshotHandler.CreateSnapshot)
+		v2.GET("/units/:unit_id/snapshots", apimeta.Spec{
+			Response: gin.H{"snapshots": []gin.H{}},
+		}, middleware.RateLimitMiddleware(false), snapshotHandler.ListSnapshots)
+		v2.GET("/units/:unit_id/snapshot", apimeta.Spec{
+			Response: gin.H{"requested_version": 0, "exact": false, "current_version": 0, "snapshot": models.UnitSnapshot{}},
+		}, middleware.RateLimitMiddleware(false), snapshotHandler.GetSnapshot)
+
 		// ============ 训练队列管理 ============
 		queueHandler := handlers.NewQueueHandlerV2()
 
 		// 在训练单元下创建队列
-		v2.POST("/units/:unit_id/queues", middleware.RateLimitMiddleware(false), queueHandler.CreateTrainingQueue)
-		v2.POST("/units/:unit_id/queues/batch", middleware.RateLimitMiddleware(true), queueHandler.BatchCreateQueues)
-		v2.GET("/units/:unit_id/queues", middleware.RateLimitMiddleware(false), queueHandler.ListTrainingQueues)
+		v2.POST("/units/:unit_id/queues", apimeta.Spec{
+			Request:  handlers.CreateQueueRequest{},
+			Response: gin.H{"queue_id": "", "queue": models.TrainingQueue{}},
+		}, middleware.RateLimitMiddleware(false), queueHandler.CreateTrainingQueue)
+		v2.POST("/units/:unit_id/queues/batch", apimeta.Spec{
+			Request:  handlers.BatchCreateQueuesRequest{},
+			Response: gin.H{"queue_ids": []string{}, "created_count": 0},
+		}, middleware.RateLimitMiddleware(true), queueHandler.BatchCreateQueues)
+		v2.GET("/units/:unit_id/queues", apimeta.Spec{
+			Response: gin.H{"queues": []models.TrainingQueue{}, "count": 0},
+		}, middleware.RateLimitMiddleware(false), queueHandler.ListTrainingQueues)
+		v2.POST("/units/:unit_id/queues/batch-delete", apimeta.Spec{
+			Request:  handlers.BatchDeleteQueuesRequest{},
+			Response: gin.H{"message": "", "deleted_count": 0, "expires_at": time.Time{}},
+		}, middleware.RateLimitMiddleware(true), queueHandler.BatchDeleteQueues)
 
 		// 重新排序队列
-		v2.POST("/units/:unit_id/queues/reorder", middleware.RateLimitMiddleware(false), queueHandler.ReorderQueues)
+		v2.POST("/units/:unit_id/queues/reorder", apimeta.Spec{
+			Request:  handlers.ReorderQueuesRequest{},
+			Response: gin.H{"message": "", "count": 0},
+		}, middleware.RateLimitMiddleware(false), queueHandler.ReorderQueues)
 
 		// 训练队列操作
 		queues := v2.Group("/queues")
 		{
-			queues.GET("/:queue_id", middleware.RateLimitMiddleware(false), queueHandler.GetTrainingQueue)
-			queues.PUT("/:queue_id", middleware.RateLimitMiddleware(false), queueHandler.UpdateTrainingQueue)
-			queues.DELETE("/:queue_id", middleware.RateLimitMiddleware(false), queueHandler.DeleteTrainingQueue)
+			queues.GET("/:queue_id", apimeta.Spec{
+				Response: gin.H{"queue": models.TrainingQueue{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.GetTrainingQueue)
+			queues.PUT("/:queue_id", apimeta.Spec{
+				Request:  handlers.UpdateQueueRequest{},
+				Response: gin.H{"queue": models.TrainingQueue{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.UpdateTrainingQueue)
+			queues.DELETE("/:queue_id", apimeta.Spec{
+				Response: gin.H{"message": "", "expires_at": time.Time{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.DeleteTrainingQueue)
 
 			// Python客户端专用端点（执行控制）
-			queues.POST("/:queue_id/start", middleware.RateLimitMiddleware(false), queueHandler.StartQueue)
-			queues.POST("/:queue_id/complete", middleware.RateLimitMiddleware(false), queueHandler.CompleteQueue)
-			queues.POST("/:queue_id/fail", middleware.RateLimitMiddleware(false), queueHandler.FailQueue)
+			queues.POST("/:queue_id/start", apimeta.Spec{
+				Response: gin.H{"queue": models.TrainingQueue{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.StartQueue)
+			queues.POST("/:queue_id/complete", apimeta.Spec{
+				Request:  handlers.CompleteQueueRequest{},
+				Response: gin.H{"queue": models.TrainingQueue{}, "receipt": models.ExecutionReceipt{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.CompleteQueue)
+			queues.POST("/:queue_id/fail", apimeta.Spec{
+				Request:  handlers.FailQueueRequest{},
+				Response: gin.H{"queue": models.TrainingQueue{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.FailQueue)
+
+			// 训练过程指标上报与控制命令
+			queues.POST("/:queue_id/metrics", apimeta.Spec{
+				Request:  handlers.ReportMetricsRequest{},
+				Response: gin.H{"anomaly_detected": false, "anomalies": []models.AnomalyEvent{}, "commands": []models.QueueCommand{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.ReportMetrics)
+			queues.POST("/:queue_id/stop", apimeta.Spec{
+				Request:  handlers.StopQueueRequest{},
+				Response: gin.H{"command": models.QueueCommand{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.RequestStop)
+			queues.GET("/:queue_id/commands", apimeta.Spec{
+				Response: gin.H{"commands": []models.QueueCommand{}},
+			}, middleware.RateLimitMiddleware(false), queueHandler.ListQueueCommands)
+
+			// 调度说明：解释队列为何尚未开始
+			queues.GET("/:queue_id/placement", apimeta.Spec{
+				Response: gin.H{
+					"queue_id": "", "status": "", "waiting": false, "position": 0, "order": 0,
+					"ahead": []gin.H{}, "running": []string{}, "connection_status": "",
+					"last_heartbeat": time.Time{}, "blockers": []gin.H{}, "summary": "",
+				},
+			}, middleware.RateLimitMiddleware(false), queueHandler.GetQueuePlacement)
+		}
+
+		v2.POST("/commands/:command_id/ack", apimeta.Spec{
+			Response: gin.H{"command": models.QueueCommand{}},
+		}, middleware.RateLimitMiddleware(false), queueHandler.AcknowledgeCommand)
+
+		// ============ 指标异常检测 ============
+		anomalyHandler := handlers.NewAnomalyHandler()
+		v2.POST("/units/:unit_id/anomaly-rules", apimeta.Spec{
+			Request:  handlers.CreateAnomalyRuleRequest{},
+			Response: gin.H{"rule": models.AnomalyRule{}},
+		}, middleware.RateLimitMiddleware(false), anomalyHandler.CreateAnomalyRule)
+		v2.GET("/units/:unit_id/anomaly-rules", apimeta.Spec{
+			Response: gin.H{"rules": []models.AnomalyRule{}},
+		}, middleware.RateLimitMiddleware(false), anomalyHandler.ListAnomalyRules)
+		v2.DELETE("/anomaly-rules/:rule_id", apimeta.Spec{
+			Response: gin.H{"message": ""},
+		}, middleware.RateLimitMiddleware(false), anomalyHandler.DeleteAnomalyRule)
+		v2.GET("/queues/:queue_id/anomalies", apimeta.Spec{
+			Response: gin.H{"anomalies": []models.AnomalyEvent{}},
+		}, middleware.RateLimitMiddleware(false), anomalyHandler.ListQueueAnomalies)
+
+		// ============ 通知路由 ============
+		notificationHandler := handlers.NewNotificationHandler()
+		v2.PUT("/groups/:group_id/notifications", apimeta.Spec{
+			Request:  handlers.NotificationRouteRequest{},
+			Response: gin.H{"notifications": models.NotificationRoute{}},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.SetGroupNotifications)
+		v2.GET("/groups/:group_id/notifications", apimeta.Spec{
+			Response: gin.H{"notifications": models.NotificationRoute{}},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.GetGroupNotifications)
+		v2.DELETE("/groups/:group_id/notifications", apimeta.Spec{
+			Response: gin.H{"message": ""},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.DeleteGroupNotifications)
+		v2.PUT("/units/:unit_id/notifications", apimeta.Spec{
+			Request:  handlers.NotificationRouteRequest{},
+			Response: gin.H{"notifications": models.NotificationRoute{}, "effective": models.NotificationRoute{}},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.SetUnitNotifications)
+		v2.GET("/units/:unit_id/notifications", apimeta.Spec{
+			Response: gin.H{"notifications": models.NotificationRoute{}, "effective": models.NotificationRoute{}},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.GetUnitNotifications)
+		v2.DELETE("/units/:unit_id/notifications", apimeta.Spec{
+			Response: gin.H{"message": ""},
+		}, middleware.RateLimitMiddleware(false), notificationHandler.DeleteUnitNotifications)
+
+		// ============ 服务账号（服务账号自身不能管理服务账号） ============
+		serviceAccountHandler := handlers.NewServiceAccountHandler()
+		serviceAccounts := v2.Group("/service-accounts", apimeta.Guard(middleware.HumanOnlyMiddleware(), apimeta.RejectServiceAccounts))
+		{
+			serviceAccounts.POST("", apimeta.Spec{
+				Request:  handlers.CreateServiceAccountRequest{},
+				Response: gin.H{"service_account": gin.H{}, "api_key": ""},
+			}, middleware.RateLimitMiddleware(false), serviceAccountHandler.CreateServiceAccount)
+			serviceAccounts.GET("", apimeta.Spec{
+				Response: gin.H{"service_accounts": []gin.H{}},
+			}, middleware.RateLimitMiddleware(false), serviceAccountHandler.ListServiceAccounts)
+			serviceAccounts.PUT("/:account_id", apimeta.Spec{
+				Request:  handlers.UpdateServiceAccountRequest{},
+				Response: gin.H{"service_account": gin.H{}},
+			}, middleware.RateLimitMiddleware(false), serviceAccountHandler.UpdateServiceAccount)
+			serviceAccounts.DELETE("/:account_id", apimeta.Spec{
+				Response: gin.H{"message": ""},
+			}, middleware.RateLimitMiddleware(false), serviceAccountHandler.DeleteServiceAccount)
+			serviceAccounts.POST("/:account_id/rotate-key", apimeta.Spec{
+				Response: gin.H{"service_account": gin.H{}, "api_key": ""},
+			}, middleware.RateLimitMiddleware(false), serviceAccountHandler.RotateServiceAccountKey)
+		}
+
+		// ============ 回收站 ============
+		trashHandler := handlers.NewTrashHandler()
+		trash := v2.Group("/trash")
+		{
+			trash.GET("", apimeta.Spec{
+				Response: gin.H{"units": []handlers.TrashedUnit{}, "queues": []handlers.TrashedQueue{}, "retention_days": 0},
+			}, middleware.RateLimitMiddleware(false), trashHandler.ListTrash)
+			trash.POST("/units/:unit_id/restore", apimeta.Spec{
+				Response: gin.H{"unit_id": "", "restored_queues": 0, "message": ""},
+			}, middleware.RateLimitMiddleware(false), trashHandler.RestoreUnit)
+			trash.POST("/queues/:queue_id/restore", apimeta.Spec{
+				Response: gin.H{"queue_id": "", "message": ""},
+			}, middleware.RateLimitMiddleware(false), trashHandler.RestoreQueue)
+			trash.DELETE("/units/:unit_id", apimeta.Spec{
+				Response: gin.H{"unit_id": "", "purged_queues": int64(0), "message": ""},
+			}, middleware.RateLimitMiddleware(false), trashHandler.PurgeUnit)
+			trash.DELETE("/queues/:queue_id", apimeta.Spec{
+				Response: gin.H{"queue_id": "", "message": ""},
+			}, middleware.RateLimitMiddleware(false), trashHandler.PurgeQueue)
+		}
+
+		// ============ 超大结果流式上传（请求体为原始JSON，不做结构描述） ============
+		payloadHandler := handlers.NewPayloadHandler()
+		v2.PUT("/queues/:queue_id/payloads/:kind", apimeta.Spec{
+			Response: gin.H{"payload": models.PayloadBlob{}, "reference": models.JSONB{}},
+		}, middleware.RateLimitMiddleware(true), payloadHandler.UploadPayload)
+		v2.GET("/queues/:queue_id/payloads", apimeta.Spec{
+			Response: gin.H{"payloads": []models.PayloadBlob{}},
+		}, middleware.RateLimitMiddleware(false), payloadHandler.ListQueuePayloads)
+		v2.GET("/payloads/:payload_id", apimeta.Spec{}, middleware.RateLimitMiddleware(false), payloadHandler.GetPayload)
+
+		// ============ 执行回执 ============
+		receiptHandler := handlers.NewReceiptHandler()
+		v2.GET("/queues/:queue_id/receipt", apimeta.Spec{
+			Response: gin.H{"receipt": models.ExecutionReceipt{}},
+		}, middleware.RateLimitMiddleware(false), receiptHandler.GetQueueReceipt)
+		v2.GET("/queues/:queue_id/receipt/verify", apimeta.Spec{
+			Response: gin.H{"receipt_id": "", "signature_valid": false, "content_matches": false},
+		}, middleware.RateLimitMiddleware(false), receiptHandler.VerifyQueueReceipt)
+		v2.GET("/units/:unit_id/receipts", apimeta.Spec{
+			Response: gin.H{"receipts": []models.ExecutionReceipt{}, "count": 0},
+		}, middleware.RateLimitMiddleware(false), receiptHandler.ListUnitReceipts)
+		v2.GET("/receipts/chain/verify", apimeta.Spec{
+			Response: gin.H{"receipts": 0, "chain_valid": false, "breaks": []services.ChainBreak{}},
+		}, middleware.RateLimitMiddleware(false), receiptHandler.VerifyReceiptChain)
+
+		// ============ 组织管理员报表（format=csv时返回CSV） ============
+		adminReportHandler := handlers.NewAdminReportHandler()
+		reports := v2.Group("/admin/reports", apimeta.Guard(middleware.AdminMiddleware(),
+			apimeta.RequireRole(models.RoleAdmin), apimeta.RejectServiceAccounts))
+		{
+			reports.GET("/overview", apimeta.Spec{
+				Response: gin.H{"overview": services.OrgOverview{}},
+			}, middleware.RateLimitMiddleware(false), adminReportHandler.GetOverview)
+			reports.GET("/members", apimeta.Spec{
+				Response: gin.H{"since": time.Time{}, "members": []services.MemberUsage{}},
+			}, middleware.RateLimitMiddleware(false), adminReportHandler.ListMembers)
+			reports.GET("/top-consumers", apimeta.Spec{
+				Response: gin.H{"since": time.Time{}, "consumers": []services.MemberUsage{}},
+			}, middleware.RateLimitMiddleware(false), adminReportHandler.GetTopConsumers)
+			reports.GET("/stale", apimeta.Spec{
+				Response: gin.H{"cutoff": time.Time{}, "units": []services.StaleUnit{}, "queues": []services.StaleQueue{}},
+			}, middleware.RateLimitMiddleware(false), adminReportHandler.GetStaleResources)
 		}
 	}
 }
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+)
+
+// AnomalyService 在指标上报时执行训练单元的异常检测规则
+type AnomalyService struct {
+	notifier *NotificationService
+}
+
+func NewAnomalyService() *AnomalyService {
+	return &AnomalyService{notifier: NewNotificationService()}
+}
+
+// Evaluate 对队列最新一次指标上报执行规则，返回本次新触发的异常
+func (s *AnomalyService) Evaluate(queue *models.TrainingQueue, report *models.MetricReport) ([]models.AnomalyEvent, error) {
+	var rules []models.AnomalyRule
+	if err := database.DB.Where("unit_id = ? AND enabled = ?", queue.UnitID, true).
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load anomaly rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var states []models.AnomalyRuleState
+	if err := database.DB.Where("queue_id = ?", queue.ID).
+		Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to load anomaly rule states: %w", err)
+	}
+	stateByRule := make(map[string]*models.AnomalyRuleState, len(states))
+	for i := range states {
+		stateByRule[states[i].RuleID] = &states[i]
+	}
+
+	events := make([]models.AnomalyEvent, 0)
+	for i := range rules {
+		rule := &rules[i]
+
+		state, ok := stateByRule[rule.ID]
+		if !ok {
+			state = &models.AnomalyRuleState{RuleID: rule.ID, QueueID: queue.ID}
+		}
+		if state.Triggered {
+			continue
+		}
+
+		metric, value, message, violated, changed := s.check(rule, state, report)
+		if violated {
+			state.Triggered = true
+			changed = true
+		}
+		if changed {
+			if err := database.DB.Save(state).Error; err != nil {
+				log.Printf("Failed to save anomaly rule state for queue %s: %v", queue.ID, err)
+				continue
+			}
+		}
+		if !violated {
+			continue
+		}
+
+		event := models.AnomalyEvent{
+			QueueID:  queue.ID,
+			UnitID:   queue.UnitID,
+			RuleID:   rule.ID,
+			RuleType: rule.Type,
+			Metric:   metric,
+			Value:    strconv.FormatFloat(value, 'g', -1, 64),
+			Message:  message,
+			UserID:   queue.UserID,
+		}
+		if err := database.DB.Create(&event).Error; err != nil {
+			log.Printf("Failed to record anomaly for queue %s: %v", queue.ID, err)
+			continue
+		}
+		events = append(events, event)
+
+		s.act(rule, queue, &event)
+	}
+
+	if len(events) > 0 && !queue.AnomalyDetected {
+		queue.AnomalyDetected = true
+		database.DB.Model(queue).Update("anomaly_detected", true)
+	}
+
+	return events, nil
+}
+
+// act 执行规则触发后的动作：通知与提前停止
+func (s *AnomalyService) act(rule *models.AnomalyRule, queue *models.TrainingQueue, event *models.AnomalyEvent) {
+	if rule.Notify {
+		s.notifier.SendQueueAnomaly(queue, map[string]interface{}{
+			"rule_id":   rule.ID,
+			"rule_type": rule.Type,
+			"metric":    event.Metric,
+			"value":     event.Value,
+			"message":   event.Message,
+		})
+	}
+
+	if rule.EarlyStop {
+		if _, err := IssueCommand(queue, "stop", event.Message, "rule:"+rule.ID); err != nil {
+			log.Printf("Failed to request early stop for queue %s: %v", queue.ID, err)
+		}
+	}
+}
+
+// check 检查单条规则，返回触发的指标、值、描述、是否触发，以及规则状态是否有变化
+func (s *AnomalyService) check(rule *models.AnomalyRule, state *models.AnomalyRuleState, report *models.MetricReport) (string, float64, string, bool, bool) {
+	switch rule.Type {
+	case models.AnomalyRuleNaN:
+		names := []string{rule.Metric}
+		if rule.Metric == "" {
+			names = make([]string, 0, len(report.Metrics))
+			for name := range report.Metrics {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		for _, name := range names {
+			value, ok := metricValue(report.Metrics[name])
+			if ok && (math.IsNaN(value) || math.IsInf(value, 0)) {
+				return name, value, fmt.Sprintf("指标 %s 为 %v", name, value), true, false
+			}
+		}
+
+	case models.AnomalyRuleBounds:
+		value, ok := metricValue(report.Metrics[rule.Metric])
+		if !ok || math.IsNaN(value) {
+			return "", 0, "", false, false
+		}
+		if rule.Min != nil && value < *rule.Min {
+			return rule.Metric, value, fmt.Sprintf("指标 %s=%v 低于下界 %v", rule.Metric, value, *rule.Min), true, false
+		}
+		if rule.Max != nil && value > *rule.Max {
+			return rule.Metric, value, fmt.Sprintf("指标 %s=%v 高于上界 %v", rule.Metric, value, *rule.Max), true, false
+		}
+
+	case models.AnomalyRuleNoImprovement:
+		if rule.Patience <= 0 {
+			return "", 0, "", false, false
+		}
+		value, ok := metricValue(report.Metrics[rule.Metric])
+		if !ok || math.IsNaN(value) {
+			return "", 0, "", false, false
+		}
+
+		// 增量维护最佳值及其后未改进的上报次数
+		if state.Best == nil || improves(value, *state.Best, rule.Mode) {
+			state.Best = &value
+			state.Stale = 0
+			return "", 0, "", false, true
+		}
+		state.Stale++
+		if state.Stale >= rule.Patience {
+			return rule.Metric, value, fmt.Sprintf("指标 %s 连续 %d 次上报没有改进（最佳值 %v）", rule.Metric, state.Stale, *state.Best), true, true
+		}
+		return "", 0, "", false, true
+	}
+
+	return "", 0, "", false, false
+}
+
+// improves 判断candidate是否严格优于best（mode为max时越大越好，否则越小越好）
+func improves(candidate, best float64, mode string) bool {
+	if mode == "max" {
+		return candidate > best
+	}
+	return candidate < best
+}
+
+// metricValue 解析指标值，支持以字符串形式上报的"NaN"/"Inf"（JSON无法表示）
+func metricValue(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
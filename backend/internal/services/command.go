@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IssueCommand 向队列下发控制命令，已有相同的待处理命令时直接返回该命令
+// issuedBy 为下发命令的账号ID或规则标识
+func IssueCommand(queue *models.TrainingQueue, command, reason, issuedBy string) (*models.QueueCommand, error) {
+	var existing models.QueueCommand
+	err := database.DB.Where("queue_id = ? AND command = ? AND status = ?", queue.ID, command, "pending").
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load pending commands: %w", err)
+	}
+
+	cmd := models.QueueCommand{
+		ID:       "cmd_" + uuid.New().String()[:8],
+		QueueID:  queue.ID,
+		UnitID:   queue.UnitID,
+		Command:  command,
+		Reason:   reason,
+		IssuedBy: issuedBy,
+		Status:   "pending",
+		UserID:   queue.UserID,
+	}
+
+	if err := database.DB.Create(&cmd).Error; err != nil {
+		return nil, fmt.Errorf("failed to create command: %w", err)
+	}
+
+	return &cmd, nil
+}
+
+// ExpireCommands 队列结束运行后将其待处理命令标记为过期，避免继续下发
+func ExpireCommands(queueID string) error {
+	return database.DB.Model(&models.QueueCommand{}).
+		Where("queue_id = ? AND status = ?", queueID, "pending").
+		Update("status", "expired").Error
+}
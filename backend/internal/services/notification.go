@@ -0,0 +1,222 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"MLQueue/internal/config"
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NotificationService 按组/单元的通知路由分发训练队列事件
+type NotificationService struct {
+	webhook *WebhookService
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{webhook: NewWebhookService()}
+}
+
+// ResolveRoute 计算训练单元的生效通知路由：以组级配置为默认，单元级非空字段覆盖
+// 没有任何配置或单元被静默时返回nil
+func ResolveRoute(unit *models.TrainingUnit) (*models.NotificationRoute, error) {
+	var groupRoute, unitRoute models.NotificationRoute
+	hasGroup, err := findRoute(&groupRoute, "group_id = ? AND unit_id = ?", unit.GroupID, "")
+	if err != nil {
+		return nil, err
+	}
+	hasUnit, err := findRoute(&unitRoute, "unit_id = ?", unit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasGroup && !hasUnit {
+		return nil, nil
+	}
+
+	effective := models.NotificationRoute{
+		GroupID: unit.GroupID,
+		UnitID:  unit.ID,
+		UserID:  unit.UserID,
+	}
+	if hasGroup {
+		effective.WebhookURL = groupRoute.WebhookURL
+		effective.SlackWebhookURL = groupRoute.SlackWebhookURL
+		effective.SlackChannel = groupRoute.SlackChannel
+		effective.EmailTo = groupRoute.EmailTo
+		effective.Events = groupRoute.Events
+	}
+
+	if hasUnit {
+		if unitRoute.Muted {
+			return nil, nil
+		}
+		if unitRoute.WebhookURL != "" {
+			effective.WebhookURL = unitRoute.WebhookURL
+		}
+		if unitRoute.SlackWebhookURL != "" {
+			effective.SlackWebhookURL = unitRoute.SlackWebhookURL
+		}
+		if unitRoute.SlackChannel != "" {
+			effective.SlackChannel = unitRoute.SlackChannel
+		}
+		if unitRoute.EmailTo != "" {
+			effective.EmailTo = unitRoute.EmailTo
+		}
+		if unitRoute.Events != nil {
+			effective.Events = unitRoute.Events
+		}
+	}
+
+	return &effective, nil
+}
+
+func findRoute(route *models.NotificationRoute, query string, args ...interface{}) (bool, error) {
+	err := database.DB.Where(query, args...).First(route).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NotifyQueue 将队列事件发送给用户级Webhook及训练单元的生效通知路由
+func (ns *NotificationService) NotifyQueue(queue *models.TrainingQueue, event WebhookEvent) {
+	ns.webhook.SendWebhook(event, queue.UserID)
+
+	var unit models.TrainingUnit
+	if err := database.DB.Where("id = ?", queue.UnitID).First(&unit).Error; err != nil {
+		return
+	}
+
+	route, err := ResolveRoute(&unit)
+	if err != nil {
+		log.Printf("Failed to resolve notification route for unit %s: %v", unit.ID, err)
+		return
+	}
+	if route == nil || !ns.webhook.isEventSubscribed(route.Events, event.Event) {
+		return
+	}
+
+	if route.WebhookURL != "" {
+		go ns.webhook.sendEvent(route.WebhookURL, event)
+	}
+	if route.SlackWebhookURL != "" {
+		go ns.sendSlack(route, &unit, event)
+	}
+	if route.EmailTo != "" {
+		go ns.sendEmail(route, &unit, event)
+	}
+}
+
+// sendSlack posts a text message to a Slack incoming webhook
+func (ns *NotificationService) sendSlack(route *models.NotificationRoute, unit *models.TrainingUnit, event WebhookEvent) {
+	message := map[string]string{
+		"text": summarizeEvent(unit, event),
+	}
+	if route.SlackChannel != "" {
+		message["channel"] = route.SlackChannel
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Failed to marshal slack payload: %v", err)
+		return
+	}
+
+	ns.webhook.sendWithRetry(route.SlackWebhookURL, payload, config.AppConfig.Webhook.RetryCount)
+}
+
+// sendEmail sends the event to the route's email list (skipped when SMTP is not configured)
+func (ns *NotificationService) sendEmail(route *models.NotificationRoute, unit *models.TrainingUnit, event WebhookEvent) {
+	smtpCfg := config.AppConfig.SMTP
+	if smtpCfg.Host == "" {
+		log.Printf("SMTP not configured, skipping email notification for unit %s", unit.ID)
+		return
+	}
+
+	recipients := make([]string, 0)
+	for _, addr := range strings.Split(route.EmailTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("[MLQueue] %s: %s", event.Event, event.QueueID)
+	body := summarizeEvent(unit, event)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		smtpCfg.From, strings.Join(recipients, ", "), subject, body)
+
+	var auth smtp.Auth
+	if smtpCfg.Username != "" {
+		auth = smtp.PlainAuth("", smtpCfg.Username, smtpCfg.Password, smtpCfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%s", smtpCfg.Host, smtpCfg.Port)
+	if err := smtp.SendMail(addr, auth, smtpCfg.From, recipients, []byte(msg)); err != nil {
+		log.Printf("Failed to send email notification for unit %s: %v", unit.ID, err)
+	}
+}
+
+// summarizeEvent 生成用于Slack/邮件的单行事件描述
+func summarizeEvent(unit *models.TrainingUnit, event WebhookEvent) string {
+	text := fmt.Sprintf("[MLQueue] %s | unit %s (%s) | queue %s | status %s",
+		event.Event, unit.Name, unit.ID, event.QueueID, event.Status)
+	if msg, ok := event.Result["message"].(string); ok && msg != "" {
+		text += " | " + msg
+	}
+	if msg, ok := event.Result["error"].(string); ok && msg != "" {
+		text += " | " + msg
+	}
+	return text
+}
+
+// SendQueueStarted Helper functions to send specific queue events
+func (ns *NotificationService) SendQueueStarted(queue *models.TrainingQueue) {
+	ns.NotifyQueue(queue, WebhookEvent{
+		Event:     "queue.started",
+		QueueID:   queue.ID,
+		Status:    "running",
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+func (ns *NotificationService) SendQueueCompleted(queue *models.TrainingQueue) {
+	ns.NotifyQueue(queue, WebhookEvent{
+		Event:     "queue.completed",
+		QueueID:   queue.ID,
+		Status:    "completed",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Result:    queue.Metrics,
+	})
+}
+
+func (ns *NotificationService) SendQueueFailed(queue *models.TrainingQueue) {
+	ns.NotifyQueue(queue, WebhookEvent{
+		Event:     "queue.failed",
+		QueueID:   queue.ID,
+		Status:    "failed",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Result:    map[string]interface{}{"error": queue.ErrorMsg},
+	})
+}
+
+func (ns *NotificationService) SendQueueAnomaly(queue *models.TrainingQueue, detail map[string]interface{}) {
+	ns.NotifyQueue(queue, WebhookEvent{
+		Event:     "queue.anomaly",
+		QueueID:   queue.ID,
+		Status:    "anomaly",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Result:    detail,
+	})
+}
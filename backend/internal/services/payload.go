@@ -0,0 +1,194 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"MLQueue/internal/config"
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPayloadTooLarge = errors.New("payload exceeds maximum stream size")
+	ErrPayloadInvalid  = errors.New("payload is not valid JSON")
+)
+
+// PayloadStore 将超大的result/metrics流式写入文件存储，避免JSONB行膨胀
+type PayloadStore struct {
+	dir      string
+	maxBytes int64
+}
+
+func NewPayloadStore() *PayloadStore {
+	return &PayloadStore{
+		dir:      config.AppConfig.Payload.StorageDir,
+		maxBytes: config.AppConfig.Payload.MaxStreamBytes,
+	}
+}
+
+// Save 流式保存payload并记录元数据，返回保存后的记录
+func (ps *PayloadStore) Save(queue *models.TrainingQueue, kind string, body io.Reader) (*models.PayloadBlob, error) {
+	if err := os.MkdirAll(ps.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create payload directory: %w", err)
+	}
+
+	id := "payload_" + uuid.New().String()[:8]
+	path := filepath.Join(ps.dir, id+".json")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payload file: %w", err)
+	}
+
+	// 多读1字节用于判断是否超出上限
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(body, ps.maxBytes+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write payload: %w", err)
+	}
+	if size > ps.maxBytes {
+		os.Remove(path)
+		return nil, ErrPayloadTooLarge
+	}
+
+	if err := validateJSONFile(path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	blob := models.PayloadBlob{
+		ID:          id,
+		QueueID:     queue.ID,
+		Kind:        kind,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StoragePath: path,
+		UserID:      queue.UserID,
+	}
+
+	if err := database.DB.Create(&blob).Error; err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to store payload metadata: %w", err)
+	}
+
+	return &blob, nil
+}
+
+// RemoveForQueues 删除队列关联的payload文件及记录（回收站清理时调用）
+func (ps *PayloadStore) RemoveForQueues(queueIDs []string) error {
+	if len(queueIDs) == 0 {
+		return nil
+	}
+
+	var blobs []models.PayloadBlob
+	if err := database.DB.Where("queue_id IN ?", queueIDs).Find(&blobs).Error; err != nil {
+		return err
+	}
+	return ps.remove(blobs)
+}
+
+// RemoveSuperseded 删除队列同类payload中除keepID以外的旧上传（重新上传后调用）
+func (ps *PayloadStore) RemoveSuperseded(queueID, kind, keepID string) error {
+	var blobs []models.PayloadBlob
+	if err := database.DB.Where("queue_id = ? AND kind = ? AND id <> ?", queueID, kind, keepID).
+		Find(&blobs).Error; err != nil {
+		return err
+	}
+	return ps.remove(blobs)
+}
+
+// Remove 删除单个payload的文件及记录
+func (ps *PayloadStore) Remove(blob *models.PayloadBlob) error {
+	return ps.remove([]models.PayloadBlob{*blob})
+}
+
+func (ps *PayloadStore) remove(blobs []models.PayloadBlob) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(blobs))
+	for i, blob := range blobs {
+		if err := os.Remove(blob.StoragePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		ids[i] = blob.ID
+	}
+
+	return database.DB.Where("id IN ?", ids).Delete(&models.PayloadBlob{}).Error
+}
+
+// PayloadReference 队列result/metrics中保存的payload引用
+func PayloadReference(blob *models.PayloadBlob) models.JSONB {
+	return models.JSONB{
+		"payload_ref": blob.ID,
+		"size":        blob.Size,
+		"sha256":      blob.SHA256,
+		"url":         "/v2/payloads/" + blob.ID,
+	}
+}
+
+// IsPayloadReference 判断result/metrics字段是否为payload引用
+func IsPayloadReference(data models.JSONB) bool {
+	_, ok := data["payload_ref"]
+	return ok
+}
+
+// JSONSize 返回数据序列化为JSON后的字节数
+func JSONSize(data map[string]interface{}) int {
+	if data == nil {
+		return 0
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return 0
+	}
+	return len(bytes)
+}
+
+// validateJSONFile 以流式方式校验文件为单个JSON对象
+func validateJSONFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ErrPayloadInvalid
+	}
+
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return ErrPayloadInvalid
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+
+	// 顶层对象结束后不允许再有其他内容
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrPayloadInvalid
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MLQueue/internal/config"
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ReceiptAlgorithm = "Ed25519"
+
+var (
+	receiptKeyOnce sync.Once
+	receiptKey     ed25519.PrivateKey
+	receiptKeyErr  error
+)
+
+// LoadReceiptKey 加载回执签名私钥（RECEIPT_SIGNING_KEY为base64编码的32字节种子）
+// 未配置时读取RECEIPT_KEY_FILE，文件不存在则生成种子并写入，保证重启后密钥不变
+func LoadReceiptKey() (ed25519.PrivateKey, error) {
+	receiptKeyOnce.Do(func() {
+		cfg := config.AppConfig.Receipt
+		encoded := cfg.SigningKey
+		if encoded == "" {
+			encoded, receiptKeyErr = loadOrCreateSeedFile(cfg.KeyFile)
+			if receiptKeyErr != nil {
+				return
+			}
+		}
+
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			receiptKeyErr = fmt.Errorf("receipt signing key must be a base64 encoded %d-byte seed", ed25519.SeedSize)
+			return
+		}
+		receiptKey = ed25519.NewKeyFromSeed(seed)
+	})
+	return receiptKey, receiptKeyErr
+}
+
+func loadOrCreateSeedFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return string(data), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read receipt key file: %w", err)
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", fmt.Errorf("failed to generate receipt key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(seed)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create receipt key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(encoded+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write receipt key file: %w", err)
+	}
+	log.Printf("Generated receipt signing key at %s", path)
+	return encoded, nil
+}
+
+// RegisterReceiptKey 记录当前公钥，密钥轮换后仍可按key_id校验历史回执
+func RegisterReceiptKey() error {
+	key, err := LoadReceiptKey()
+	if err != nil {
+		return err
+	}
+	publicKey := key.Public().(ed25519.PublicKey)
+
+	record := models.ReceiptSigningKey{
+		KeyID:     ReceiptKeyID(publicKey),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+	return database.DB.Where("key_id = ?", record.KeyID).
+		FirstOrCreate(&record).Error
+}
+
+// ReceiptKeyID 公钥指纹（公钥SHA-256的前16位十六进制）
+func ReceiptKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// ReceiptService 生成并校验训练队列的签名执行回执
+type ReceiptService struct {
+	key ed25519.PrivateKey
+}
+
+func NewReceiptService() *ReceiptService {
+	key, err := LoadReceiptKey()
+	if err != nil {
+		log.Printf("Failed to load receipt signing key: %v", err)
+	}
+	return &ReceiptService{key: key}
+}
+
+// PublicKey 返回当前用于签发回执的公钥
+func (rs *ReceiptService) PublicKey() ed25519.PublicKey {
+	if rs.key == nil {
+		return nil
+	}
+	return rs.key.Public().(ed25519.PublicKey)
+}
+
+// PublicKeys 返回所有签发过回执的公钥（含已轮换的）
+func (rs *ReceiptService) PublicKeys() ([]models.ReceiptSigningKey, error) {
+	var keys []models.ReceiptSigningKey
+	err := database.DB.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// publicKeyByID 按key_id查找公钥，当前密钥无需查询数据库
+func (rs *ReceiptService) publicKeyByID(keyID string) ed25519.PublicKey {
+	if current := rs.PublicKey(); current != nil && ReceiptKeyID(current) == keyID {
+		return current
+	}
+
+	var record models.ReceiptSigningKey
+	if err := database.DB.Where("key_id = ?", keyID).First(&record).Error; err != nil {
+		return nil
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(record.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil
+	}
+	return publicKey
+}
+
+// IssueReceipt 为已完成的队列生成签名回执并追加保存
+func (rs *ReceiptService) IssueReceipt(queue *models.TrainingQueue) (*models.ExecutionReceipt, error) {
+	if queue.Status != "completed" || queue.CompletedAt == nil {
+		return nil, fmt.Errorf("queue %s is not completed", queue.ID)
+	}
+	if rs.key == nil {
+		return nil, errors.New("receipt signing key is not available")
+	}
+
+	// PostgreSQL时间戳精度为微秒，截断后签名才能在读取后复现
+	var startedAt *time.Time
+	if queue.StartedAt != nil {
+		t := normalizeTime(*queue.StartedAt)
+		startedAt = &t
+	}
+
+	receipt := models.ExecutionReceipt{
+		ID:             "receipt_" + uuid.New().String()[:8],
+		QueueID:        queue.ID,
+		UnitID:         queue.UnitID,
+		ParametersHash: HashJSON(queue.Parameters),
+		ResultHash:     HashJSON(queue.Result),
+		MetricsHash:    HashJSON(queue.Metrics),
+		StartedAt:      startedAt,
+		CompletedAt:    normalizeTime(*queue.CompletedAt),
+		Algorithm:      ReceiptAlgorithm,
+		KeyID:          ReceiptKeyID(rs.PublicKey()),
+		UserID:         queue.UserID,
+	}
+
+	// 同一用户的回执串行签发，保证签名链不分叉
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "receipt:"+queue.UserID).Error; err != nil {
+			return fmt.Errorf("failed to lock receipt chain: %w", err)
+		}
+
+		var prev models.ExecutionReceipt
+		err := tx.Where("user_id = ?", queue.UserID).
+			Order("sequence DESC, issued_at DESC").
+			First(&prev).Error
+		if err == nil {
+			receipt.PrevSignature = prev.Signature
+			receipt.Sequence = prev.Sequence + 1
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			receipt.Sequence = 1
+		} else {
+			return fmt.Errorf("failed to load previous receipt: %w", err)
+		}
+
+		receipt.IssuedAt = normalizeTime(time.Now())
+		receipt.Signature = hex.EncodeToString(ed25519.Sign(rs.key, []byte(ReceiptPayload(&receipt))))
+
+		if err := tx.Create(&receipt).Error; err != nil {
+			return fmt.Errorf("failed to store receipt: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// VerifySignature 校验回执签名是否由本服务签发且未被篡改
+// 回执可来自数据库或用户提交的回执文件，只依赖key_id对应的公钥
+func (rs *ReceiptService) VerifySignature(receipt *models.ExecutionReceipt) bool {
+	if receipt.Algorithm != ReceiptAlgorithm {
+		return false
+	}
+	publicKey := rs.publicKeyByID(receipt.KeyID)
+	if publicKey == nil {
+		return false
+	}
+	signature, err := hex.DecodeString(receipt.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(publicKey, []byte(ReceiptPayload(receipt)), signature)
+}
+
+// ChainBreak 签名链中断的位置
+type ChainBreak struct {
+	ReceiptID string `json:"receipt_id"`
+	Sequence  int64  `json:"sequence"`
+	Reason    string `json:"reason"`
+}
+
+// VerifyChain 按签发顺序校验用户的全部回执：签名有效且每张回执都链接到上一张
+func (rs *ReceiptService) VerifyChain(userID string) (int, []ChainBreak, error) {
+	var receipts []models.ExecutionReceipt
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("sequence ASC, issued_at ASC").
+		Find(&receipts).Error; err != nil {
+		return 0, nil, err
+	}
+
+	breaks := make([]ChainBreak, 0)
+	prevSignature := ""
+	for i := range receipts {
+		receipt := &receipts[i]
+		if !rs.VerifySignature(receipt) {
+			breaks = append(breaks, ChainBreak{ReceiptID: receipt.ID, Sequence: receipt.Sequence, Reason: "invalid_signature"})
+		}
+		if receipt.PrevSignature != prevSignature {
+			breaks = append(breaks, ChainBreak{ReceiptID: receipt.ID, Sequence: receipt.Sequence, Reason: "broken_link"})
+		}
+		prevSignature = receipt.Signature
+	}
+
+	return len(receipts), breaks, nil
+}
+
+// VerifyQueue 校验队列当前数据是否与回执中的哈希一致
+func (rs *ReceiptService) VerifyQueue(receipt *models.ExecutionReceipt, queue *models.TrainingQueue) map[string]bool {
+	return map[string]bool{
+		"parameters": HashJSON(queue.Parameters) == receipt.ParametersHash,
+		"result":     HashJSON(queue.Result) == receipt.ResultHash,
+		"metrics":    HashJSON(queue.Metrics) == receipt.MetricsHash,
+	}
+}
+
+// ReceiptPayload 回执的规范化签名内容：各字段按固定顺序以换行连接
+// 第三方可据此用公布的公钥离线校验签名
+func ReceiptPayload(receipt *models.ExecutionReceipt) string {
+	startedAt := ""
+	if receipt.StartedAt != nil {
+		startedAt = formatReceiptTime(*receipt.StartedAt)
+	}
+
+	return strings.Join([]string{
+		receipt.Algorithm,
+		receipt.KeyID,
+		strconv.FormatInt(receipt.Sequence, 10),
+		receipt.ID,
+		receipt.QueueID,
+		receipt.UnitID,
+		receipt.UserID,
+		receipt.ParametersHash,
+		receipt.ResultHash,
+		receipt.MetricsHash,
+		startedAt,
+		formatReceiptTime(receipt.CompletedAt),
+		formatReceiptTime(receipt.IssuedAt),
+		receipt.PrevSignature,
+	}, "\n")
+}
+
+// HashJSON 计算JSONB的SHA-256哈希（json.Marshal按key排序，结果稳定）
+func HashJSON(data models.JSONB) string {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		bytes = []byte("null")
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func formatReceiptTime(t time.Time) string {
+	return normalizeTime(t).Format(time.RFC3339Nano)
+}
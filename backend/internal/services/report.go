@@ -0,0 +1,276 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+)
+
+// activeHeartbeatWindow 与连接状态检查一致，10秒内有心跳视为在线
+const activeHeartbeatWindow = 10 * time.Second
+
+// MemberUsage 单个成员在统计区间内的资源使用汇总
+type MemberUsage struct {
+	UserID          string  `json:"user_id"`
+	Name            string  `json:"name"`
+	Email           string  `json:"email"`
+	Units           int64   `json:"units"`
+	ActiveUnits     int64   `json:"active_units"`
+	RunningQueues   int64   `json:"running_queues"`
+	PendingQueues   int64   `json:"pending_queues"`
+	CompletedQueues int64   `json:"completed_queues"`
+	FailedQueues    int64   `json:"failed_queues"`
+	FailureRate     float64 `json:"failure_rate"`
+	GPUHours        float64 `json:"gpu_hours"`
+}
+
+// OrgOverview 组织整体汇总
+type OrgOverview struct {
+	Since           time.Time `json:"since"`
+	Members         int64     `json:"members"`
+	Units           int64     `json:"units"`
+	ActiveUnits     int64     `json:"active_units"`
+	RunningQueues   int64     `json:"running_queues"`
+	PendingQueues   int64     `json:"pending_queues"`
+	CompletedQueues int64     `json:"completed_queues"`
+	FailedQueues    int64     `json:"failed_queues"`
+	FailureRate     float64   `json:"failure_rate"`
+	GPUHours        float64   `json:"gpu_hours"`
+}
+
+// StaleUnit 长时间无心跳且没有运行中队列的训练单元
+type StaleUnit struct {
+	UnitID        string     `json:"unit_id"`
+	Name          string     `json:"name"`
+	UserID        string     `json:"user_id"`
+	LastHeartbeat *time.Time `json:"last_heartbeat"`
+	PendingQueues int64      `json:"pending_queues"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// StaleQueue 长时间未开始执行的待执行队列
+type StaleQueue struct {
+	QueueID   string    `json:"queue_id"`
+	Name      string    `json:"name"`
+	UnitID    string    `json:"unit_id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CollectMemberUsage 汇总所有成员的训练单元、队列状态及GPU时长（在数据库中按用户聚合）
+// 运行中/待执行数为当前值，完成/失败数统计since之后结束的队列，GPU时长统计since之后开始的队列
+func CollectMemberUsage(since time.Time) ([]MemberUsage, error) {
+	var members []models.User
+	if err := database.DB.Where("type = ?", models.UserTypeHuman).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*MemberUsage, len(members))
+	result := make([]MemberUsage, len(members))
+	for i, m := range members {
+		result[i] = MemberUsage{UserID: m.ID, Name: m.Name}
+		if m.Email != nil {
+			result[i].Email = *m.Email
+		}
+		usage[m.ID] = &result[i]
+	}
+
+	var unitRows []struct {
+		UserID      string
+		Units       int64
+		ActiveUnits int64
+	}
+	if err := database.DB.Model(&models.TrainingUnit{}).
+		Select(`user_id, COUNT(*) AS units,
+			COUNT(*) FILTER (WHERE last_heartbeat >= ? OR EXISTS (
+				SELECT 1 FROM training_queues
+				WHERE training_queues.unit_id = training_units.id
+					AND training_queues.status = 'running'
+					AND training_queues.deleted_at IS NULL)) AS active_units`,
+			time.Now().Add(-activeHeartbeatWindow)).
+		Group("user_id").
+		Scan(&unitRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range unitRows {
+		if u, ok := usage[row.UserID]; ok {
+			u.Units = row.Units
+			u.ActiveUnits = row.ActiveUnits
+		}
+	}
+
+	// 失败队列可能从未开始执行，按结束时间统计
+	var statusRows []struct {
+		UserID string
+		Status string
+		Count  int64
+	}
+	if err := database.DB.Model(&models.TrainingQueue{}).
+		Select("user_id, status, COUNT(*) AS count").
+		Where("status IN ? OR (status IN ? AND COALESCE(completed_at, updated_at) >= ?)",
+			[]string{"running", "pending"}, []string{"completed", "failed"}, since).
+		Group("user_id, status").
+		Scan(&statusRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range statusRows {
+		u, ok := usage[row.UserID]
+		if !ok {
+			continue
+		}
+		switch row.Status {
+		case "running":
+			u.RunningQueues = row.Count
+		case "pending":
+			u.PendingQueues = row.Count
+		case "completed":
+			u.CompletedQueues = row.Count
+		case "failed":
+			u.FailedQueues = row.Count
+		}
+	}
+
+	// GPU时长 = 运行时长 × GPU数量（队列参数gpus，其次训练单元配置gpus，默认1）
+	var gpuRows []struct {
+		UserID   string
+		GPUHours float64
+	}
+	if err := database.DB.Table("training_queues AS q").
+		Select(`q.user_id, SUM(
+			EXTRACT(EPOCH FROM (COALESCE(q.completed_at, NOW()) - q.started_at)) / 3600 *
+			COALESCE(`+gpuField("q.parameters")+`, `+gpuField("u.config")+`, 1)) AS gpu_hours`).
+		Joins("LEFT JOIN training_units AS u ON u.id = q.unit_id").
+		Where("q.deleted_at IS NULL AND q.started_at >= ?", since).
+		Where("q.status = ? OR q.completed_at IS NOT NULL", "running").
+		Group("q.user_id").
+		Scan(&gpuRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range gpuRows {
+		if u, ok := usage[row.UserID]; ok {
+			u.GPUHours = row.GPUHours
+		}
+	}
+
+	for i := range result {
+		result[i].FailureRate = failureRate(result[i].CompletedQueues, result[i].FailedQueues)
+	}
+
+	return result, nil
+}
+
+// SummarizeUsage 将成员汇总合并为组织整体数据
+func SummarizeUsage(members []MemberUsage, since time.Time) OrgOverview {
+	overview := OrgOverview{Since: since, Members: int64(len(members))}
+	for _, m := range members {
+		overview.Units += m.Units
+		overview.ActiveUnits += m.ActiveUnits
+		overview.RunningQueues += m.RunningQueues
+		overview.PendingQueues += m.PendingQueues
+		overview.CompletedQueues += m.CompletedQueues
+		overview.FailedQueues += m.FailedQueues
+		overview.GPUHours += m.GPUHours
+	}
+	overview.FailureRate = failureRate(overview.CompletedQueues, overview.FailedQueues)
+	return overview
+}
+
+// TopConsumers 按GPU时长降序返回前limit个成员
+func TopConsumers(members []MemberUsage, limit int) []MemberUsage {
+	sorted := make([]MemberUsage, len(members))
+	copy(sorted, members)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GPUHours > sorted[j].GPUHours
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// FindStaleResources 查找cutoff之前起再无心跳的训练单元，以及cutoff之前创建仍未执行的队列
+func FindStaleResources(cutoff time.Time) ([]StaleUnit, []StaleQueue, error) {
+	var units []models.TrainingUnit
+	if err := database.DB.
+		Where("(last_heartbeat < ? OR (last_heartbeat IS NULL AND created_at < ?))", cutoff, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM training_queues WHERE training_queues.unit_id = training_units.id AND training_queues.status = ? AND training_queues.deleted_at IS NULL)", "running").
+		Order("last_heartbeat ASC NULLS FIRST").
+		Find(&units).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pendingCounts := make(map[string]int64)
+	if len(units) > 0 {
+		unitIDs := make([]string, len(units))
+		for i, unit := range units {
+			unitIDs[i] = unit.ID
+		}
+
+		var counts []struct {
+			UnitID string
+			Count  int64
+		}
+		if err := database.DB.Model(&models.TrainingQueue{}).
+			Select("unit_id, COUNT(*) AS count").
+			Where("unit_id IN ? AND status = ?", unitIDs, "pending").
+			Group("unit_id").
+			Scan(&counts).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, c := range counts {
+			pendingCounts[c.UnitID] = c.Count
+		}
+	}
+
+	staleUnits := make([]StaleUnit, len(units))
+	for i, unit := range units {
+		staleUnits[i] = StaleUnit{
+			UnitID:        unit.ID,
+			Name:          unit.Name,
+			UserID:        unit.UserID,
+			LastHeartbeat: unit.LastHeartbeat,
+			PendingQueues: pendingCounts[unit.ID],
+			CreatedAt:     unit.CreatedAt,
+		}
+	}
+
+	var queues []models.TrainingQueue
+	if err := database.DB.Select("id", "name", "unit_id", "user_id", "created_at").
+		Where("status = ? AND created_at < ?", "pending", cutoff).
+		Order("created_at ASC").
+		Find(&queues).Error; err != nil {
+		return nil, nil, err
+	}
+
+	staleQueues := make([]StaleQueue, len(queues))
+	for i, q := range queues {
+		staleQueues[i] = StaleQueue{
+			QueueID:   q.ID,
+			Name:      q.Name,
+			UnitID:    q.UnitID,
+			UserID:    q.UserID,
+			CreatedAt: q.CreatedAt,
+		}
+	}
+
+	return staleUnits, staleQueues, nil
+}
+
+// gpuField 读取JSONB列中数值类型的gpus字段（非数值或负数视为未设置）
+func gpuField(column string) string {
+	value := "(" + column + "->>'gpus')::float"
+	// 嵌套CASE保证先判断类型再转换（AND不保证求值顺序）
+	return "CASE WHEN jsonb_typeof(" + column + "->'gpus') = 'number' THEN " +
+		"CASE WHEN " + value + " >= 0 THEN " + value + " END END"
+}
+
+func failureRate(completed, failed int64) float64 {
+	if completed+failed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(completed+failed)
+}
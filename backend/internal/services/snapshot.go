@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SnapshotUnit 保存训练单元当前版本的快照，同一版本已有快照时直接返回已有快照
+func SnapshotUnit(unit *models.TrainingUnit, trigger string) (*models.UnitSnapshot, error) {
+	var existing models.UnitSnapshot
+	err := database.DB.Where("unit_id = ? AND version = ?", unit.ID, unit.Version).
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	var queues []models.TrainingQueue
+	if err := database.DB.Where("unit_id = ?", unit.ID).
+		Order("\"order\" ASC").
+		Find(&queues).Error; err != nil {
+		return nil, fmt.Errorf("failed to load queues: %w", err)
+	}
+
+	queueList := make([]interface{}, len(queues))
+	for i, q := range queues {
+		queueList[i] = map[string]interface{}{
+			"queue_id":   q.ID,
+			"name":       q.Name,
+			"parameters": q.Parameters,
+			"order":      q.Order,
+			"status":     q.Status,
+			"created_by": q.CreatedBy,
+		}
+	}
+
+	snapshot := models.UnitSnapshot{
+		UnitID:      unit.ID,
+		Version:     unit.Version,
+		Name:        unit.Name,
+		Description: unit.Description,
+		Config:      unit.Config,
+		Queues:      models.JSONB{"queues": queueList},
+		Trigger:     trigger,
+		UserID:      unit.UserID,
+	}
+
+	if err := database.DB.Create(&snapshot).Error; err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// UnitSnapshotter 定期为版本发生变化的训练单元保存快照
+type UnitSnapshotter struct {
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewUnitSnapshotter(interval time.Duration) *UnitSnapshotter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &UnitSnapshotter{
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins periodic snapshotting in background (no-op if interval is not positive)
+func (us *UnitSnapshotter) Start() {
+	if us.interval <= 0 {
+		log.Println("Periodic unit snapshots disabled")
+		return
+	}
+
+	us.wg.Add(1)
+	go func() {
+		defer us.wg.Done()
+
+		ticker := time.NewTicker(us.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-us.ctx.Done():
+				return
+			case <-ticker.C:
+				us.snapshotChanged()
+			}
+		}
+	}()
+}
+
+// snapshotChanged snapshots every unit whose version is newer than its latest snapshot
+func (us *UnitSnapshotter) snapshotChanged() {
+	var units []models.TrainingUnit
+	if err := database.DB.
+		Where("version > COALESCE((SELECT MAX(version) FROM unit_snapshots WHERE unit_snapshots.unit_id = training_units.id), 0)").
+		Find(&units).Error; err != nil {
+		log.Printf("Failed to find changed units for snapshot: %v", err)
+		return
+	}
+
+	for i := range units {
+		if _, err := SnapshotUnit(&units[i], "periodic"); err != nil {
+			log.Printf("Failed to snapshot unit %s: %v", units[i].ID, err)
+		}
+	}
+}
+
+// Stop gracefully stops the snapshotter
+func (us *UnitSnapshotter) Stop() {
+	us.cancel()
+	us.wg.Wait()
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"MLQueue/internal/config"
+	"MLQueue/internal/database"
+	"MLQueue/internal/models"
+)
+
+// TrashRetention 回收站保留期，超过后数据被永久删除
+func TrashRetention() time.Duration {
+	return time.Duration(config.AppConfig.Trash.RetentionDays) * 24 * time.Hour
+}
+
+// TrashPurger 定期永久删除超过保留期的回收站数据
+type TrashPurger struct {
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewTrashPurger(interval time.Duration) *TrashPurger {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TrashPurger{
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins periodic purging in background
+func (tp *TrashPurger) Start() {
+	tp.wg.Add(1)
+	go func() {
+		defer tp.wg.Done()
+
+		ticker := time.NewTicker(tp.interval)
+		defer ticker.Stop()
+
+		tp.purge()
+		for {
+			select {
+			case <-tp.ctx.Done():
+				return
+			case <-ticker.C:
+				tp.purge()
+			}
+		}
+	}()
+}
+
+// purge permanently deletes trashed units and queues past retention
+func (tp *TrashPurger) purge() {
+	cutoff := time.Now().Add(-TrashRetention())
+
+	var unitIDs []string
+	database.DB.Unscoped().
+		Model(&models.TrainingUnit{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &unitIDs)
+
+	// 单独删除的队列（所属单元仍在使用中）
+	var queueIDs []string
+	database.DB.Unscoped().
+		Model(&models.TrainingQueue{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &queueIDs)
+
+	queues, err := PurgeQueues(queueIDs)
+	if err != nil {
+		log.Printf("Failed to purge trashed queues: %v", err)
+	}
+	units, unitQueues, err := PurgeUnits(unitIDs)
+	if err != nil {
+		log.Printf("Failed to purge trashed units: %v", err)
+	}
+
+	if queues+unitQueues > 0 || units > 0 {
+		log.Printf("Purged %d units and %d queues from trash", units, queues+unitQueues)
+	}
+}
+
+// PurgeQueues 永久删除队列及其payload文件和关联记录（不检查是否在回收站中）
+func PurgeQueues(queueIDs []string) (int64, error) {
+	if len(queueIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := NewPayloadStore().RemoveForQueues(queueIDs); err != nil {
+		return 0, fmt.Errorf("failed to remove payloads: %w", err)
+	}
+	if err := removeQueueRecords(queueIDs); err != nil {
+		return 0, fmt.Errorf("failed to remove queue records: %w", err)
+	}
+
+	result := database.DB.Unscoped().
+		Where("id IN ?", queueIDs).
+		Delete(&models.TrainingQueue{})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeUnits 永久删除训练单元及其全部队列（含未删除的队列）、快照、通知路由等关联记录
+// 返回删除的单元数和队列数
+func PurgeUnits(unitIDs []string) (int64, int64, error) {
+	if len(unitIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	var queueIDs []string
+	if err := database.DB.Unscoped().
+		Model(&models.TrainingQueue{}).
+		Where("unit_id IN ?", unitIDs).
+		Pluck("id", &queueIDs).Error; err != nil {
+		return 0, 0, err
+	}
+	queues, err := PurgeQueues(queueIDs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := database.DB.Where("unit_id IN ?", unitIDs).Delete(&models.AnomalyRule{}).Error; err != nil {
+		return 0, queues, fmt.Errorf("failed to remove anomaly rules: %w", err)
+	}
+	if err := database.DB.Where("unit_id IN ?", unitIDs).Delete(&models.UnitSnapshot{}).Error; err != nil {
+		return 0, queues, fmt.Errorf("failed to remove unit snapshots: %w", err)
+	}
+	if err := database.DB.Where("unit_id IN ?", unitIDs).Delete(&models.NotificationRoute{}).Error; err != nil {
+		return 0, queues, fmt.Errorf("failed to remove notification routes: %w", err)
+	}
+
+	result := database.DB.Unscoped().
+		Where("id IN ?", unitIDs).
+		Delete(&models.TrainingUnit{})
+	return result.RowsAffected, queues, result.Error
+}
+
+// removeQueueRecords 删除队列的指标上报、异常记录、规则状态及控制命令
+func removeQueueRecords(queueIDs []string) error {
+	if len(queueIDs) == 0 {
+		return nil
+	}
+
+	for _, model := range []interface{}{
+		&models.MetricReport{},
+		&models.AnomalyEvent{},
+		&models.AnomalyRuleState{},
+		&models.QueueCommand{},
+	} {
+		if err := database.DB.Where("queue_id IN ?", queueIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop gracefully stops the purger
+func (tp *TrashPurger) Stop() {
+	tp.cancel()
+	tp.wg.Wait()
+}
@@ -17,9 +17,18 @@ type WebhookService struct {
 	client *http.Client
 }
 
+func NewWebhookService() *WebhookService {
+	return &WebhookService{
+		client: &http.Client{
+			Timeout: time.Duration(config.AppConfig.Webhook.TimeoutSeconds) * time.Second,
+		},
+	}
+}
+
 type WebhookEvent struct {
 	Event     string                 `json:"event"`
-	TaskID    string                 `json:"task_id"`
+	TaskID    string                 `json:"task_id,omitempty"`
+	QueueID   string                 `json:"queue_id,omitempty"`
 	Status    string                 `json:"status"`
 	Timestamp string                 `json:"timestamp"`
 	Result    map[string]interface{} `json:"result,omitempty"`
@@ -37,18 +46,23 @@ func (ws *WebhookService) SendWebhook(event WebhookEvent, userID string) {
 			continue
 		}
 
-		go ws.sendWithRetry(webhook.URL, event, config.AppConfig.Webhook.RetryCount)
+		go ws.sendEvent(webhook.URL, event)
 	}
 }
 
-// sendWithRetry attempts to send webhook with retries
-func (ws *WebhookService) sendWithRetry(url string, event WebhookEvent, maxRetries int) {
+// sendEvent marshals the event and posts it to url with retries
+func (ws *WebhookService) sendEvent(url string, event WebhookEvent) {
 	payload, err := json.Marshal(event)
 	if err != nil {
 		log.Printf("Failed to marshal webhook payload: %v", err)
 		return
 	}
 
+	ws.sendWithRetry(url, payload, config.AppConfig.Webhook.RetryCount)
+}
+
+// sendWithRetry attempts to send webhook with retries
+func (ws *WebhookService) sendWithRetry(url string, payload []byte, maxRetries int) {
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff
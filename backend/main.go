@@ -14,6 +14,7 @@ import (
 	"MLQueue/internal/database"
 	"MLQueue/internal/queue"
 	"MLQueue/internal/routes"
+	"MLQueue/internal/services"
 )
 
 func main() {
@@ -31,11 +32,25 @@ func main() {
 		log.Fatalf("Failed to initialize Redis: %v", err)
 	}
 
+	if err := services.RegisterReceiptKey(); err != nil {
+		log.Fatalf("Failed to load receipt signing key: %v", err)
+	}
+
 	// Initialize queue manager with worker pool
 	queueManager := queue.NewQueueManager(cfg.Queue.WorkerCount)
 	queueManager.Start()
 	defer queueManager.Stop()
 
+	// Periodically purge expired trash
+	trashPurger := services.NewTrashPurger(time.Hour)
+	trashPurger.Start()
+	defer trashPurger.Stop()
+
+	// Periodically snapshot changed training units
+	snapshotter := services.NewUnitSnapshotter(time.Duration(cfg.Snapshot.IntervalMinutes) * time.Minute)
+	snapshotter.Start()
+	defer snapshotter.Stop()
+
 	// Setup routes
 	router := routes.SetupRouter(queueManager)
 
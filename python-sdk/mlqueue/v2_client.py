@@ -5,6 +5,7 @@ Python驱动架构：客户端控制训练执行，云端管理配置
 from typing import Optional, Dict, Any, List
 import requests
 import json
+import math
 
 from .v2_models import Group, TrainingUnit, TrainingQueue, QueueStatus
 from .exceptions import (
@@ -628,7 +629,7 @@ class MLQueueV2Client:
     def complete_queue(
         self,
         queue_id: str,
-        result: Dict[str, Any],
+        result: Optional[Dict[str, Any]] = None,
         metrics: Optional[Dict[str, Any]] = None
     ) -> bool:
         """
@@ -636,19 +637,61 @@ class MLQueueV2Client:
 
         Args:
             queue_id: 队列ID
-            result: 训练结果
-            metrics: 训练指标
+            result: 训练结果（为空时保留服务器已有内容，如流式上传的payload）
+            metrics: 训练指标（为空时保留训练过程中上报的最新指标）
 
         Returns:
             是否成功
         """
-        data = {
-            "result": result,
-            "metrics": metrics or {}
-        }
+        data = {}
+        if result:
+            data["result"] = result
+        if metrics:
+            data["metrics"] = _encode_metrics(metrics)
         self._request('POST', f'/queues/{queue_id}/complete', data=data)
         return True
 
+    def report_metrics(
+        self,
+        queue_id: str,
+        metrics: Dict[str, Any],
+        step: Optional[int] = None
+    ) -> Dict[str, Any]:
+        """
+        训练过程中上报指标（Python客户端调用），服务器据此执行异常检测规则
+
+        NaN/Inf等非有限浮点数会以字符串"NaN"、"Inf"、"-Inf"发送，
+        标准JSON无法表示这些值，而"nan"类检测规则正依赖它们。
+
+        Args:
+            queue_id: 队列ID
+            metrics: 当前指标，如 {"loss": 0.12, "accuracy": 0.95}
+            step: 训练步数
+
+        Returns:
+            上报响应，包含：
+            - anomaly_detected: 队列是否已检测到异常
+            - anomalies: 本次上报触发的异常事件
+            - commands: 待处理的命令（如stop），处理后应调用ack_command确认
+        """
+        data: Dict[str, Any] = {"metrics": _encode_metrics(metrics)}
+        if step is not None:
+            data["step"] = step
+        return self._request('POST', f'/queues/{queue_id}/metrics', data=data)
+
+    def ack_command(self, command_id: str) -> Dict[str, Any]:
+        """
+        确认已处理服务器下发的命令（Python客户端调用）
+
+        Args:
+            command_id: 命令ID（心跳或指标上报响应中commands[].command_id）
+
+        Returns:
+            确认后的命令信息
+        """
+        response = self._request('POST', f'/commands/{command_id}/ack')
+        return response.get('command', {})
+
     def fail_queue(self, queue_id: str, error_msg: str) -> bool:
         """
         标记队列为失败状态（Python客户端调用）
@@ -663,3 +706,16 @@ class MLQueueV2Client:
         data = {"error_msg": error_msg}
         self._request('POST', f'/queues/{queue_id}/fail', data=data)
         return True
+
+
+def _encode_metrics(value: Any) -> Any:
+    """将指标中的NaN/Inf转换为字符串，使请求体为合法JSON"""
+    if isinstance(value, float) and not math.isfinite(value):
+        if math.isnan(value):
+            return "NaN"
+        return "Inf" if value > 0 else "-Inf"
+    if isinstance(value, dict):
+        return {k: _encode_metrics(v) for k, v in value.items()}
+    if isinstance(value, (list, tuple)):
+        return [_encode_metrics(v) for v in value]
+    return value
@@ -2,7 +2,7 @@
 MLQueue V2 数据模型
 新架构：User -> Group -> TrainingUnit -> TrainingQueue
 """
-from typing import Optional, Dict, Any, List
+from typing import Optional, Dict, Any, List, Callable
 from datetime import datetime
 from enum import Enum
 import threading
@@ -192,6 +192,11 @@ class TrainingUnit:
         self._heartbeat_running = False
         self._heartbeat_interval = 6  # 每6秒发送一次心跳（5-8秒范围内）
 
+        # 服务器下发的停止命令（队列ID集合），由心跳或指标上报响应带回
+        self._stop_requested: set = set()
+        self._stop_lock = threading.Lock()
+        self._on_stop: Optional[Callable[[Dict[str, Any]], None]] = None
+
     def add_queue(
         self,
         name: str,
@@ -342,6 +347,7 @@ class TrainingUnit:
                 response = self.client.heartbeat(self.id)
                 # 可选：记录心跳状态
                 # print(f"[心跳] {self.name}: {response.get('connection_status')}")
+                self.handle_commands(response.get('commands') or [])
             except Exception as e:
                 # 心跳失败不应中断程序，只记录错误
                 print(f"[心跳错误] {self.name}: {str(e)}")
@@ -349,20 +355,73 @@ class TrainingUnit:
             # 等待下一次心跳
             time.sleep(self._heartbeat_interval)
 
-    def start_heartbeat(self, interval: int = 6):
+    def handle_commands(self, commands: List[Dict[str, Any]]):
+        """
+        处理服务器下发的命令并确认
+
+        stop命令会标记对应队列为待停止（见is_stop_requested），
+        并调用start_heartbeat时传入的on_stop回调。
+
+        Args:
+            commands: 心跳或指标上报响应中的commands
+        """
+        for command in commands:
+            if command.get('command') == 'stop':
+                queue_id = command.get('queue_id')
+                with self._stop_lock:
+                    first = queue_id not in self._stop_requested
+                    self._stop_requested.add(queue_id)
+
+                if first:
+                    print(f"[命令] {self.name}: 队列 {queue_id} 收到停止请求 ({command.get('reason', '')})")
+                    if self._on_stop:
+                        try:
+                            self._on_stop(command)
+                        except Exception as e:
+                            print(f"[命令错误] {self.name}: on_stop回调失败: {str(e)}")
+
+            try:
+                self.client.ack_command(command['command_id'])
+            except Exception as e:
+                # 确认失败时命令保持pending，下次心跳会再次返回
+                print(f"[命令错误] {self.name}: 确认命令失败: {str(e)}")
+
+    def is_stop_requested(self, queue_id: str) -> bool:
+        """
+        队列是否已收到服务器的停止请求（用户手动停止或异常检测规则触发）
+
+        训练循环应定期检查该方法，返回True时尽快结束训练并调用fail/complete。
+
+        Args:
+            queue_id: 队列ID
+
+        Returns:
+            是否需要停止
+        """
+        with self._stop_lock:
+            return queue_id in self._stop_requested
+
+    def start_heartbeat(
+        self,
+        interval: int = 6,
+        on_stop: Optional[Callable[[Dict[str, Any]], None]] = None
+    ):
         """
         启动心跳线程
 
         Python客户端应在创建训练单元后立即启动心跳，
         以保持与云端的连接状态。心跳会在后台线程中每隔5-8秒自动发送。
+        心跳响应中的stop命令会被自动处理并确认。
 
         Args:
             interval: 心跳间隔（秒），推荐5-8秒，默认6秒
+            on_stop: 收到停止命令时的回调（在心跳线程中调用），参数为命令信息
         """
         if self._heartbeat_running:
             print(f"[心跳] {self.name}: 心跳已在运行")
             return
 
+        self._on_stop = on_stop
         self._heartbeat_interval = interval
         self._heartbeat_running = True
 
@@ -467,6 +526,7 @@ class TrainingQueue:
         self.metrics = metrics
         self.error_message = error_message
         self.metadata = metadata or {}
+        self.stop_requested = False  # 服务器是否已请求提前停止
         self.started_at = started_at
         self.completed_at = completed_at
         self.created_at = created_at
@@ -508,6 +568,37 @@ class TrainingQueue:
             self.completed_at = datetime.now().isoformat()
         return success
 
+    def report_metrics(
+        self,
+        metrics: Dict[str, Any],
+        step: Optional[int] = None
+    ) -> Dict[str, Any]:
+        """
+        训练过程中上报指标
+
+        响应中的stop命令会被自动确认，并将stop_requested置为True，
+        训练循环应据此提前结束。
+
+        Args:
+            metrics: 当前指标（NaN/Inf会自动转换为字符串上报）
+            step: 训练步数
+
+        Returns:
+            上报响应（anomaly_detected、anomalies、commands）
+        """
+        response = self.client.report_metrics(self.id, metrics, step)
+        self.metrics = metrics
+
+        for command in response.get('commands') or []:
+            if command.get('command') == 'stop':
+                self.stop_requested = True
+            try:
+                self.client.ack_command(command['command_id'])
+            except Exception as e:
+                print(f"[命令错误] {self.name}: 确认命令失败: {str(e)}")
+
+        return response
+
     def fail(self, error_message: str) -> bool:
         """
         标记队列为失败状态